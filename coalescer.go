@@ -0,0 +1,83 @@
+package switchbot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCommandCoalesced is returned for a command which is superseded by a newer
+// command of the same type for the same device within the coalescing window.
+var ErrCommandCoalesced = errors.New("command is coalesced into a newer command")
+
+// CommandCoalescer debounces rapid commands of the same type for the same device,
+// such as SetBrightnessCommand emitted by a UI slider, and only sends the latest
+// one after no newer command arrives within the window.
+type CommandCoalescer struct {
+	svc    *DeviceService
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingCommand
+}
+
+type pendingCommand struct {
+	ctx    context.Context
+	id     string
+	cmd    Command
+	result chan error
+	timer  *time.Timer
+}
+
+// NewCommandCoalescer returns a new CommandCoalescer which sends commands through
+// the device service after given window has elapsed since the last command.
+func (svc *DeviceService) NewCommandCoalescer(window time.Duration) *CommandCoalescer {
+	return &CommandCoalescer{
+		svc:     svc,
+		window:  window,
+		pending: map[string]*pendingCommand{},
+	}
+}
+
+// Command schedules sending a command to the device as same as (*DeviceService).Command.
+// Commands are identified by the device ID and the command name, so that commands with
+// different parameters, e.g. different brightness, are coalesced.
+// The returned channel receives the result of sending the command, or ErrCommandCoalesced
+// when the command is superseded by a newer one.
+func (cc *CommandCoalescer) Command(ctx context.Context, id string, cmd Command) <-chan error {
+	key := id + "/" + cmd.Request().Command
+
+	p := &pendingCommand{
+		ctx:    ctx,
+		id:     id,
+		cmd:    cmd,
+		result: make(chan error, 1),
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if prev, ok := cc.pending[key]; ok {
+		prev.timer.Stop()
+		prev.result <- ErrCommandCoalesced
+	}
+
+	cc.pending[key] = p
+	p.timer = time.AfterFunc(cc.window, func() { cc.send(key, p) })
+
+	return p.result
+}
+
+func (cc *CommandCoalescer) send(key string, p *pendingCommand) {
+	cc.mu.Lock()
+	if cc.pending[key] != p {
+		// superseded while waiting for the lock
+		cc.mu.Unlock()
+		return
+	}
+	delete(cc.pending, key)
+	cc.mu.Unlock()
+
+	p.result <- cc.svc.Command(p.ctx, p.id, p.cmd)
+}
@@ -0,0 +1,76 @@
+package switchbot_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nasa9084/go-switchbot/v4"
+)
+
+func TestCommandCoalescer(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			mu.Lock()
+			bodies = append(bodies, string(b))
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"statusCode":100,"body":{},"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	cc := c.Device().NewCommandCoalescer(50 * time.Millisecond)
+
+	var results []<-chan error
+	for brightness := 10; brightness <= 50; brightness += 10 {
+		cmd, err := switchbot.SetBrightnessCommand(brightness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, cc.Command(context.Background(), "84F70353A411", cmd))
+	}
+
+	for i, result := range results {
+		err := <-result
+
+		if i < len(results)-1 {
+			if !errors.Is(err, switchbot.ErrCommandCoalesced) {
+				t.Errorf("command #%d should be coalesced but %v", i, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("the last command should be sent but %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(bodies) != 1 {
+		t.Fatalf("only one request is expected but %d", len(bodies))
+	}
+
+	want := `{"command":"setBrightness","parameter":"50","commandType":"command"}
+`
+	if bodies[0] != want {
+		t.Errorf("unexpected request body:\n  got:  %s\n  want: %s", bodies[0], want)
+	}
+}
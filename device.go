@@ -1,12 +1,14 @@
 package switchbot
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,8 +17,28 @@ import (
 // SwitchBot devices and to send control commands to those devices.
 type DeviceService struct {
 	c *Client
+
+	// knownMu guards known, the set of device IDs seen in the last List call,
+	// and refreshedAt, the time when the device list was last refreshed.
+	// They are used only when WithAutoRefreshDevices is given.
+	knownMu     sync.Mutex
+	known       map[string]struct{}
+	refreshedAt time.Time
+
+	// refreshMu serializes the refreshes of the device list on cache misses, so that
+	// concurrent lookups wait for the running refresh instead of starting another one.
+	refreshMu sync.Mutex
 }
 
+// deviceRefreshInterval is the minimum interval to refresh the device list on
+// cache misses when WithAutoRefreshDevices is given.
+const deviceRefreshInterval = time.Minute
+
+// ErrDeviceNotFound is returned when the device of given ID is not found, i.e.
+// by Get, or when WithAutoRefreshDevices is given and the device ID is not found
+// even after the device list is refreshed.
+var ErrDeviceNotFound = errors.New("device not found")
+
 func newDeviceService(c *Client) *DeviceService {
 	return &DeviceService{c: c}
 }
@@ -86,6 +108,56 @@ const (
 	PasscodeStautsInvalid PasscodeStatus = "expired"
 )
 
+// AvailableCommands returns the names of the commands which the device accepts,
+// based on its type. nil is returned for the device types which accept no command,
+// e.g. sensors and hubs.
+func (device Device) AvailableCommands() []string {
+	switch device.Type {
+	case Bot:
+		return []string{"turnOn", "turnOff", "press"}
+	case Curtain:
+		return []string{"turnOn", "turnOff", "setPosition"}
+	case BlindTilt:
+		return []string{"setPosition", "fullyOpen", "closeUp", "closeDown"}
+	case RollerShade:
+		return []string{"setPosition"}
+	case Plug:
+		return []string{"turnOn", "turnOff"}
+	case PlugMiniUS, PlugMiniJP:
+		return []string{"turnOn", "turnOff", "toggle"}
+	case RelaySwitch1PM, RelaySwitch1:
+		return []string{"turnOn", "turnOff", "toggle", "setMode"}
+	case Lock:
+		return []string{"lock", "unlock", "createKey", "deleteKey"}
+	case LockPro:
+		return []string{"lock", "unlock", "deadbolt", "createKey", "deleteKey"}
+	case KeyPad, KeyPadTouch:
+		return []string{"createKey", "deleteKey"}
+	case Humidifier:
+		return []string{"turnOn", "turnOff", "setMode"}
+	case AirPurifierVOC, AirPurifierTableVOC, AirPurifierPM25, AirPurifierTablePM25:
+		return []string{"turnOn", "turnOff", "setMode"}
+	case SmartFan:
+		return []string{"turnOn", "turnOff", "setAllStatus"}
+	case BatteryCirculatorFan:
+		return []string{"turnOn", "turnOff", "setNightLightMode", "setWindMode", "setWindSpeed"}
+	case ColorBulb:
+		return []string{"turnOn", "turnOff", "toggle", "setBrightness", "setColor", "setColorTemperature"}
+	case StripLight:
+		return []string{"turnOn", "turnOff", "toggle", "setBrightness", "setColor"}
+	case CeilingLight, CeilingLightPro:
+		return []string{"turnOn", "turnOff", "toggle", "setBrightness", "setColorTemperature"}
+	case RobotVacuumCleanerS1, RobotVacuumCleanerS1Plus, WoSweeperMini:
+		return []string{"start", "stop", "dock", "PowLevel"}
+	case RobotVacuumCleanerK10PlusPro:
+		return []string{"startClean", "pause", "dock", "setVolume"}
+	case RobotVacuumCleanerS10:
+		return []string{"startClean", "pause", "dock", "setVolume", "selfClean"}
+	}
+
+	return nil
+}
+
 // InfraredDevice represents a virtual infrared remote device.
 type InfraredDevice struct {
 	ID   string            `json:"deviceId"`
@@ -94,6 +166,29 @@ type InfraredDevice struct {
 	Hub  string            `json:"hubDeviceId"`
 }
 
+// AvailableCommands returns the names of the standard commands which the infrared
+// remote device accepts, based on its type. The buttons learned for DIY devices are
+// not included because they are not reported by the API; they can be sent with
+// CustomCommand by their names.
+func (device InfraredDevice) AvailableCommands() []string {
+	switch device.Type {
+	case AirConditioner:
+		return []string{"turnOn", "turnOff", "setAll"}
+	case TV, IPTVStreamer, SetTopBox:
+		return []string{"turnOn", "turnOff", "SetChannel", "volumeAdd", "volumeSub", "channelAdd", "channelSub"}
+	case DVD:
+		return []string{"turnOn", "turnOff", "setMute", "FastForward", "Rewind", "Next", "Previous", "Pause", "Play", "Stop"}
+	case Speaker:
+		return []string{"turnOn", "turnOff", "setMute", "FastForward", "Rewind", "Next", "Previous", "Pause", "Play", "Stop", "volumeAdd", "volumeSub"}
+	case Fan:
+		return []string{"turnOn", "turnOff", "swing", "timer", "lowSpeed", "middleSpeed", "highSpeed"}
+	case Light:
+		return []string{"turnOn", "turnOff", "brightnessUp", "brightnessDown"}
+	}
+
+	return []string{"turnOn", "turnOff"}
+}
+
 // List get a list of devices, which include physical devices and virtual infrared
 // remote devices that have been added to the current user's account.
 // The first returned value is a list of physical devices refer to the SwitchBot products.
@@ -120,9 +215,220 @@ func (svc *DeviceService) List(ctx context.Context) ([]Device, []InfraredDevice,
 		return nil, nil, fmt.Errorf("unknown error %d from device list API", response.StatusCode)
 	}
 
+	svc.remember(response.Body.DeviceList, response.Body.InfraredRemoteList)
+
 	return response.Body.DeviceList, response.Body.InfraredRemoteList, nil
 }
 
+// remember replaces the set of known device IDs with given devices.
+func (svc *DeviceService) remember(devices []Device, infrared []InfraredDevice) {
+	known := make(map[string]struct{}, len(devices)+len(infrared))
+	for _, device := range devices {
+		known[device.ID] = struct{}{}
+	}
+	for _, device := range infrared {
+		known[device.ID] = struct{}{}
+	}
+
+	svc.knownMu.Lock()
+	svc.known = known
+	svc.refreshedAt = svc.c.clock()
+	svc.knownMu.Unlock()
+}
+
+func (svc *DeviceService) isKnown(id string) bool {
+	svc.knownMu.Lock()
+	defer svc.knownMu.Unlock()
+
+	_, ok := svc.known[id]
+	return ok
+}
+
+// lookup checks if the device of given ID is known when WithAutoRefreshDevices is given.
+// On a cache miss, the device list is refreshed before failing with ErrDeviceNotFound,
+// but at most once per deviceRefreshInterval so that unknown IDs do not cause a list
+// call every time. Concurrent cache misses wait for the running refresh and check
+// the refreshed list.
+func (svc *DeviceService) lookup(ctx context.Context, id string) error {
+	if !svc.c.autoRefreshDevices || svc.isKnown(id) {
+		return nil
+	}
+
+	svc.refreshMu.Lock()
+	defer svc.refreshMu.Unlock()
+
+	// the list may have been refreshed while waiting for the lock
+	known, refresh := svc.checkKnown(id)
+	if known {
+		return nil
+	}
+
+	if refresh {
+		if _, _, err := svc.List(ctx); err != nil {
+			return fmt.Errorf("refreshing device list: %w", err)
+		}
+
+		if svc.isKnown(id) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrDeviceNotFound, id)
+}
+
+// checkKnown reports whether the device of given ID is known and, if not, whether
+// the device list should be refreshed, i.e. it has not been refreshed within
+// deviceRefreshInterval.
+func (svc *DeviceService) checkKnown(id string) (known, refresh bool) {
+	svc.knownMu.Lock()
+	defer svc.knownMu.Unlock()
+
+	if _, ok := svc.known[id]; ok {
+		return true, false
+	}
+
+	return false, svc.refreshedAt.IsZero() || svc.c.clock().Sub(svc.refreshedAt) >= deviceRefreshInterval
+}
+
+// ListSummary get a list of devices and returns the number of devices for each
+// device type.
+// The first returned value is the number of physical devices for each physical
+// device type. The second returned value is the number of virtual infrared remote
+// devices for each remote type.
+func (svc *DeviceService) ListSummary(ctx context.Context) (map[PhysicalDeviceType]int, map[VirtualDeviceType]int, error) {
+	devices, infrared, err := svc.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	physicalCount := make(map[PhysicalDeviceType]int)
+	for _, d := range devices {
+		physicalCount[d.Type]++
+	}
+
+	infraredCount := make(map[VirtualDeviceType]int)
+	for _, d := range infrared {
+		infraredCount[d.Type]++
+	}
+
+	return physicalCount, infraredCount, nil
+}
+
+// ListPhysical gets a list of devices as same as List, but returns only the
+// physical devices.
+func (svc *DeviceService) ListPhysical(ctx context.Context) ([]Device, error) {
+	devices, _, err := svc.List(ctx)
+	return devices, err
+}
+
+// ListInfrared gets a list of devices as same as List, but returns only the
+// virtual infrared remote devices.
+func (svc *DeviceService) ListInfrared(ctx context.Context) ([]InfraredDevice, error) {
+	_, infrared, err := svc.List(ctx)
+	return infrared, err
+}
+
+// Get gets a list of devices and returns the device of given ID. Either of
+// the returned Device or InfraredDevice is non-nil depending on whether the
+// device is a physical device or a virtual infrared remote device.
+// ErrDeviceNotFound is returned when the ID is not found in both lists.
+func (svc *DeviceService) Get(ctx context.Context, id string) (*Device, *InfraredDevice, error) {
+	devices, infrared, err := svc.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range devices {
+		if devices[i].ID == id {
+			return &devices[i], nil, nil
+		}
+	}
+
+	for i := range infrared {
+		if infrared[i].ID == id {
+			return nil, &infrared[i], nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, id)
+}
+
+// ByHub get a list of devices and returns them grouped by the ID of the hub which
+// each device is connected to. The devices which report no hub ID are keyed by
+// the empty string.
+func (svc *DeviceService) ByHub(ctx context.Context) (map[string][]Device, error) {
+	devices, _, err := svc.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byHub := make(map[string][]Device)
+	for _, d := range devices {
+		byHub[d.Hub] = append(byHub[d.Hub], d)
+	}
+
+	return byHub, nil
+}
+
+// DeviceGroup represents a group of devices such as grouped curtains, grouped blind tilts,
+// or dual locks. A group consists of a master device and its member devices.
+type DeviceGroup struct {
+	Name    string
+	Master  Device
+	Members []Device
+}
+
+// Groups get a list of devices and assembles them into groups of a master device and its
+// members, based on the master flag and the device ID lists of each device.
+// Devices which do not belong to any group are not included.
+func (svc *DeviceService) Groups(ctx context.Context) ([]DeviceGroup, error) {
+	devices, _, err := svc.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Device, len(devices))
+	for _, d := range devices {
+		byID[d.ID] = d
+	}
+
+	var groups []DeviceGroup
+	for _, d := range devices {
+		if !d.IsMaster {
+			continue
+		}
+
+		var memberIDs []string
+		memberIDs = append(memberIDs, d.Curtains...)
+		memberIDs = append(memberIDs, d.BlindTilts...)
+		memberIDs = append(memberIDs, d.LockDeviceIDs...)
+
+		group := DeviceGroup{
+			Name:   d.GroupName,
+			Master: d,
+		}
+		seen := map[string]bool{d.ID: true}
+		for _, id := range memberIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if member, ok := byID[id]; ok {
+				group.Members = append(group.Members, member)
+			}
+		}
+
+		if len(group.Members) == 0 {
+			continue
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
 type deviceStatusResponse struct {
 	StatusCode int          `json:"statusCode"`
 	Message    string       `json:"message"`
@@ -144,7 +450,7 @@ type DeviceStatus struct {
 	IsGrouped              bool                 `json:"group"`
 	IsMoving               bool                 `json:"moving"`
 	SlidePosition          int                  `json:"slidePosition"`
-	FanMode                int                  `json:"mode"`
+	Mode                   Mode                 `json:"mode"`
 	FanSpeed               int                  `json:"speed"`
 	IsShaking              bool                 `json:"shaking"`
 	ShakeCenter            int                  `json:"shakeCenter"`
@@ -160,14 +466,132 @@ type DeviceStatus struct {
 	Weight                 float64              `json:"weight"`
 	ElectricityOfDay       int                  `json:"electricityOfDay"`
 	ElectricCurrent        float64              `json:"electricCurrent"`
-	LockState              string               `json:"lockState"`
-	DoorState              string               `json:"doorState"`
+	LockState              LockState            `json:"lockState"`
+	DoorState              DoorState            `json:"doorState"`
 	WorkingStatus          CleanerWorkingStatus `json:"workingStatus"`
 	OnlineStatus           CleanerOnlineStatus  `json:"onlineStatus"`
 	Battery                int                  `json:"battery"`
 	Version                DeviceVersion        `json:"version"`
 	Direction              string               `json:"direction"`
-	CO2                    int                  `json:"CO2"`
+	// CO2 is the CO2 concentration in ppm, which is only reported by MeterPro(CO2)
+	// devices. This is zero for other devices.
+	CO2             int              `json:"CO2"`
+	SwitchStatus    int              `json:"switchStatus"`
+	UsedElectricity float64          `json:"usedElectricity"`
+	Scale           TemperatureScale `json:"scale"`
+	// DeviceMode is the mode of Bot devices. This is empty for other devices.
+	DeviceMode BotDeviceMode `json:"deviceMode"`
+	// Volume is the voice volume (0 - 100) of Robot Vacuum Cleaner K10+ Pro and S10.
+	Volume int `json:"volume"`
+	// IsEnableCloudService is whether the cloud service is enabled for the device,
+	// as same as Device.IsEnableCloudService. This is false when the status does
+	// not report it.
+	IsEnableCloudService bool `json:"enableCloudService"`
+	// ElectricPower is the power consumption in watts reported by relay switches.
+	// Relay switches report it as "power" field, which is used for PowerState by other devices.
+	ElectricPower float64 `json:"-"`
+	// BatteryReported is whether the status reports the battery level, which is
+	// decoded from the presence of "battery" field. See also HasBattery.
+	BatteryReported bool `json:"-"`
+
+	// any other values in the status which are not mapped to the fields above,
+	// e.g. values of newly introduced devices
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (status *DeviceStatus) UnmarshalJSON(b []byte) error {
+	type alias DeviceStatus
+	aux := struct {
+		*alias
+		Power json.RawMessage `json:"power"`
+		// Battery is decoded separately to know if the battery is reported
+		Battery *int `json:"battery"`
+	}{
+		alias: (*alias)(status),
+	}
+
+	extra, err := unmarshalWithExtra(b, &aux)
+	if err != nil {
+		return err
+	}
+	status.Extra = extra
+
+	if aux.Battery != nil {
+		status.Battery = *aux.Battery
+		status.BatteryReported = true
+	}
+
+	if len(aux.Power) == 0 {
+		return nil
+	}
+
+	var power PowerState
+	if err := json.Unmarshal(aux.Power, &power); err != nil {
+		var watts float64
+		if err := json.Unmarshal(aux.Power, &watts); err != nil {
+			return fmt.Errorf("cannot unmarshal power to both of string and number: %w", err)
+		}
+
+		status.ElectricPower = watts
+
+		return nil
+	}
+
+	status.Power = power
+
+	return nil
+}
+
+// HasBattery returns true if the status reports the battery level. This is
+// useful to tell battery-powered devices with 0% battery from devices without
+// battery, e.g. mains-powered devices, because Battery is 0 for both of them.
+func (status DeviceStatus) HasBattery() bool {
+	return status.BatteryReported
+}
+
+// RelaySwitchMode returns the mode of relay switch devices.
+func (status DeviceStatus) RelaySwitchMode() RelaySwitchMode {
+	mode, _ := status.Mode.Int()
+	return RelaySwitchMode(mode)
+}
+
+// HumidifierMode returns the mode of Humidifier devices, which can be passed to
+// SetModeCommand as is. AutoMode is returned when the device is in auto mode,
+// otherwise the atomization efficiency (0 - 100) is returned as HumidifierMode.
+func (status DeviceStatus) HumidifierMode() (HumidifierMode, error) {
+	if status.Type != Humidifier {
+		return 0, fmt.Errorf("humidifier mode is not available for %s", status.Type)
+	}
+
+	if status.IsAuto {
+		return AutoMode, nil
+	}
+
+	if mode, err := status.Mode.Int(); err == nil {
+		switch mode := HumidifierMode(mode); mode {
+		case LowMode, MidMode, HighMode:
+			return mode, nil
+		}
+	}
+
+	return HumidifierMode(status.NebulizationEfficiency), nil
+}
+
+// AirPurifierMode returns the mode of Air Purifier devices, which can be passed to
+// SetAirPurifierModeCommand as is.
+func (status DeviceStatus) AirPurifierMode() (AirPurifierMode, error) {
+	switch status.Type {
+	case AirPurifierVOC, AirPurifierTableVOC, AirPurifierPM25, AirPurifierTablePM25:
+	default:
+		return 0, fmt.Errorf("air purifier mode is not available for %s", status.Type)
+	}
+
+	mode, err := status.Mode.Int()
+	if err != nil {
+		return 0, err
+	}
+
+	return AirPurifierMode(mode), nil
 }
 
 type PowerState string
@@ -189,6 +613,131 @@ const (
 	ContactTimeoutNotClose OpenState = "timeOutNotClose"
 )
 
+// BotDeviceMode represents a mode of Bot devices.
+type BotDeviceMode string
+
+const (
+	BotPressMode     BotDeviceMode = "pressMode"
+	BotSwitchMode    BotDeviceMode = "switchMode"
+	BotCustomizeMode BotDeviceMode = "customizeMode"
+)
+
+// TemperatureScale represents a unit of temperature values.
+type TemperatureScale string
+
+const (
+	Celsius    TemperatureScale = "CELSIUS"
+	Fahrenheit TemperatureScale = "FAHRENHEIT"
+)
+
+// UnmarshalJSON decodes a temperature scale. The known scales are matched
+// case-insensitively, and unknown values are kept as-is.
+func (scale *TemperatureScale) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	for _, known := range []TemperatureScale{Celsius, Fahrenheit} {
+		if strings.EqualFold(s, string(known)) {
+			*scale = known
+			return nil
+		}
+	}
+
+	*scale = TemperatureScale(s)
+
+	return nil
+}
+
+// toCelsius converts given temperature value in given scale into Celsius.
+func toCelsius(temperature float64, scale TemperatureScale) float64 {
+	if scale == Fahrenheit {
+		return (temperature - 32) * 5 / 9
+	}
+
+	return temperature
+}
+
+// LockState represents a state of the lock devices. The state is kept as reported,
+// in lower case by the status API and in upper case by the webhook; see Is.
+type LockState string
+
+const (
+	// Locked stands for the motor is rotated to locking position.
+	Locked LockState = "LOCKED"
+	// Unlocked stands for the motor is rotated to unlocking position.
+	Unlocked LockState = "UNLOCKED"
+	// Jammed stands for the motor is jammed while rotating.
+	Jammed LockState = "JAMMED"
+	// LatchBoltLocked stands for only the latch bolt is locked while the deadbolt is
+	// retracted. This is reported by Lock Pro devices.
+	LatchBoltLocked LockState = "LATCHBOLTLOCKED"
+)
+
+// Is reports whether the state is same as given state ignoring case. The status API
+// reports lock states in lower case, e.g. "locked", while the webhook reports them in
+// upper case, e.g. "LOCKED", and the state is kept as reported, so use this rather
+// than == to compare the state with the constants.
+func (state LockState) Is(other LockState) bool {
+	return strings.EqualFold(string(state), string(other))
+}
+
+// DoorState represents a state of the door reported by lock devices with door sensors.
+type DoorState string
+
+const (
+	DoorOpened  DoorState = "opened"
+	DoorClosed  DoorState = "closed"
+	DoorTimeout DoorState = "timeout"
+)
+
+// Mode represents a mode of devices, which is reported as an integer by some devices,
+// e.g. fans and relay switches, and as a string by other devices.
+type Mode struct {
+	intMode    int
+	stringMode string
+}
+
+func (mode *Mode) UnmarshalJSON(b []byte) error {
+	mode.intMode = -1 // set invalid value first
+
+	var iv int
+	if err := json.Unmarshal(b, &iv); err != nil {
+		var sv string
+		if err := json.Unmarshal(b, &sv); err != nil {
+			return fmt.Errorf("cannot unmarshal to both of int and string: %w", err)
+		}
+
+		mode.stringMode = sv
+
+		return nil
+	}
+
+	mode.intMode = iv
+
+	return nil
+}
+
+// Int returns the mode as an integer. An error is returned when the mode is
+// reported as a string.
+func (mode Mode) Int() (int, error) {
+	if mode.intMode < 0 {
+		return -1, fmt.Errorf("mode %q is not an integer", mode.stringMode)
+	}
+
+	return mode.intMode, nil
+}
+
+// String returns the mode as a string. Integer modes are formatted in decimal.
+func (mode Mode) String() string {
+	if mode.intMode < 0 {
+		return mode.stringMode
+	}
+
+	return strconv.Itoa(mode.intMode)
+}
+
 type BrightnessState struct {
 	intBrightness     int
 	ambientBrightness AmbientBrightness
@@ -265,6 +814,10 @@ const (
 // (*Client).Device().List() function.
 // See also https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#get-device-status
 func (svc *DeviceService) Status(ctx context.Context, id string) (DeviceStatus, error) {
+	if err := svc.lookup(ctx, id); err != nil {
+		return DeviceStatus{}, err
+	}
+
 	path := "/v1.1/devices/" + id + "/status"
 
 	resp, err := svc.c.get(ctx, path)
@@ -284,55 +837,543 @@ func (svc *DeviceService) Status(ctx context.Context, id string) (DeviceStatus,
 		return DeviceStatus{}, fmt.Errorf("unknown error %d from device list API", response.StatusCode)
 	}
 
+	svc.c.logUnmappedKeys(ctx, "device status", string(response.Body.Type), response.Body.Extra)
+
 	return response.Body, nil
 }
 
+// Statuses retrieves the statuses of given devices using at most concurrency
+// requests at the same time. The results and the errors are keyed by the device ID.
+// When ctx is done before all the devices are requested, the remaining devices
+// get ctx.Err() as their error.
+func (svc *DeviceService) Statuses(ctx context.Context, ids []string, concurrency int) (map[string]DeviceStatus, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		statuses = make(map[string]DeviceStatus, len(ids))
+		errs     = make(map[string]error)
+	)
+
+	queue := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for id := range queue {
+				status, err := svc.Status(ctx, id)
+
+				mu.Lock()
+				if err != nil {
+					errs[id] = err
+				} else {
+					statuses[id] = status
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+enqueue:
+	for i, id := range ids {
+		select {
+		case queue <- id:
+		case <-ctx.Done():
+			mu.Lock()
+			for _, id := range ids[i:] {
+				errs[id] = ctx.Err()
+			}
+			mu.Unlock()
+			break enqueue
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return statuses, errs
+}
+
+// BotStatus represents a status of Bot devices.
+type BotStatus struct {
+	ID      string
+	Type    PhysicalDeviceType
+	Hub     string
+	Power   PowerState
+	Battery int
+	Version DeviceVersion
+	Mode    BotDeviceMode
+}
+
+// PreferredOnCommand returns a Command to turn the Bot on, which depends on the mode
+// of the Bot: TurnOnCommand for switch mode, and PressCommand for other modes.
+func (status BotStatus) PreferredOnCommand() Command {
+	if status.Mode == BotSwitchMode {
+		return TurnOnCommand()
+	}
+
+	return PressCommand()
+}
+
+// CurtainStatus represents a status of Curtain devices.
+type CurtainStatus struct {
+	ID            string
+	Type          PhysicalDeviceType
+	Hub           string
+	IsCalibrated  bool
+	IsGrouped     bool
+	IsMoving      bool
+	SlidePosition int
+	Battery       int
+	Version       DeviceVersion
+}
+
+// MeterStatus represents a status of thermometer and hygrometer devices,
+// Meter, Indoor/Outdoor Thermo-Hygrometer, and Meter Pro.
+type MeterStatus struct {
+	ID          string
+	Type        PhysicalDeviceType
+	Hub         string
+	Temperature float64
+	Humidity    int
+	// Scale is the unit of Temperature, which is set on the device or the app.
+	// This is empty when the device does not report it.
+	Scale TemperatureScale
+	// CO2 is only available for MeterPro(CO2) devices.
+	CO2     int
+	Battery int
+	Version DeviceVersion
+}
+
+// MeterPlusStatus represents a status of Meter Plus devices, including both of
+// the US and JP models.
+type MeterPlusStatus struct {
+	ID          string
+	Type        PhysicalDeviceType
+	Hub         string
+	Temperature float64
+	Humidity    int
+	Scale       TemperatureScale
+	Battery     int
+	Version     DeviceVersion
+}
+
+// LockStatus represents a status of Lock and Lock Pro devices.
+type LockStatus struct {
+	ID           string
+	Type         PhysicalDeviceType
+	Hub          string
+	LockState    LockState
+	DoorState    DoorState
+	IsCalibrated bool
+	Battery      int
+	Version      DeviceVersion
+}
+
+// Hub2Status represents a status of Hub 2 devices, which have the built-in
+// thermometer, hygrometer and illuminance sensor but no motion sensor.
+type Hub2Status struct {
+	ID          string
+	Type        PhysicalDeviceType
+	Hub         string
+	Temperature float64
+	Humidity    int
+	// LightLevel is the level of illuminance of the ambience light, 1~20.
+	LightLevel int
+	Version    DeviceVersion
+}
+
+// PlugStatus represents a status of Plug and Plug Mini devices.
+type PlugStatus struct {
+	ID               string
+	Type             PhysicalDeviceType
+	Hub              string
+	Power            PowerState
+	Voltage          float64
+	Weight           float64
+	ElectricityOfDay int
+	ElectricCurrent  float64
+	Version          DeviceVersion
+	// InUse reports whether a load is actually drawing current, not just whether the
+	// plug is turned on.
+	InUse bool
+}
+
+// StatusTyped get the status of a physical device as same as Status, but returns
+// a typed status struct chosen by the device type, so that you can type-switch once.
+// The returned value is one of *BotStatus, *CurtainStatus, *MeterStatus, *MeterPlusStatus,
+// *Hub2Status, *LockStatus, or *PlugStatus. For other device types, *DeviceStatus is returned.
+func (svc *DeviceService) StatusTyped(ctx context.Context, id string) (interface{}, error) {
+	status, err := svc.Status(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return status.typed(), nil
+}
+
+func (status DeviceStatus) typed() interface{} {
+	switch status.Type {
+	case Bot:
+		return &BotStatus{
+			ID:      status.ID,
+			Type:    status.Type,
+			Hub:     status.Hub,
+			Power:   status.Power,
+			Battery: status.Battery,
+			Version: status.Version,
+			Mode:    status.DeviceMode,
+		}
+	case Curtain:
+		return &CurtainStatus{
+			ID:            status.ID,
+			Type:          status.Type,
+			Hub:           status.Hub,
+			IsCalibrated:  status.IsCalibrated,
+			IsGrouped:     status.IsGrouped,
+			IsMoving:      status.IsMoving,
+			SlidePosition: status.SlidePosition,
+			Battery:       status.Battery,
+			Version:       status.Version,
+		}
+	case MeterPlus, MeterPlusJP, MeterPlusUS:
+		return &MeterPlusStatus{
+			ID:          status.ID,
+			Type:        status.Type,
+			Hub:         status.Hub,
+			Temperature: status.Temperature,
+			Humidity:    status.Humidity,
+			Scale:       status.Scale,
+			Battery:     status.Battery,
+			Version:     status.Version,
+		}
+	case Hub2:
+		return &Hub2Status{
+			ID:          status.ID,
+			Type:        status.Type,
+			Hub:         status.Hub,
+			Temperature: status.Temperature,
+			Humidity:    status.Humidity,
+			LightLevel:  status.LightLevel,
+			Version:     status.Version,
+		}
+	case Meter, WoIOSensor, MeterPro, MeterProCO2:
+		return &MeterStatus{
+			ID:          status.ID,
+			Type:        status.Type,
+			Hub:         status.Hub,
+			Temperature: status.Temperature,
+			Humidity:    status.Humidity,
+			Scale:       status.Scale,
+			CO2:         status.CO2,
+			Battery:     status.Battery,
+			Version:     status.Version,
+		}
+	case Lock, LockPro:
+		return &LockStatus{
+			ID:           status.ID,
+			Type:         status.Type,
+			Hub:          status.Hub,
+			LockState:    status.LockState,
+			DoorState:    status.DoorState,
+			IsCalibrated: status.IsCalibrated,
+			Battery:      status.Battery,
+			Version:      status.Version,
+		}
+	case Plug, PlugMiniUS, PlugMiniJP:
+		return &PlugStatus{
+			ID:               status.ID,
+			Type:             status.Type,
+			Hub:              status.Hub,
+			Power:            status.Power,
+			Voltage:          status.Voltage,
+			Weight:           status.Weight,
+			ElectricityOfDay: status.ElectricityOfDay,
+			ElectricCurrent:  status.ElectricCurrent,
+			Version:          status.Version,
+			InUse:            status.ElectricCurrent > 0,
+		}
+	default:
+		return &status
+	}
+}
+
+// GenericAccessory is a device-agnostic model of a device status, which is
+// modeled after the characteristics of smart home platforms such as HomeKit.
+// The fields are nil when the device does not have the characteristic.
+type GenericAccessory struct {
+	ID   string
+	Type PhysicalDeviceType
+
+	On *bool
+	// Brightness is the brightness of lights in percent, 0-100.
+	Brightness *int
+	// Temperature is the temperature in Celsius, regardless of the scale set for the device.
+	Temperature *float64
+	// Humidity is the relative humidity in percent, 0-100.
+	Humidity *int
+	// Position is the position of window coverings in percent, 0-100.
+	Position       *int
+	Locked         *bool
+	MotionDetected *bool
+	ContactOpen    *bool
+	// Battery is the battery level in percent, 0-100, which is nil when the status does not report it.
+	Battery *int
+}
+
+// ToGenericAccessory converts the status into device-agnostic GenericAccessory,
+// so that bridges to other smart home platforms can share the mapping.
+func (status DeviceStatus) ToGenericAccessory() GenericAccessory {
+	accessory := GenericAccessory{
+		ID:   status.ID,
+		Type: status.Type,
+	}
+
+	switch status.Type {
+	case Bot, Plug, PlugMiniUS, PlugMiniJP, ColorBulb, StripLight, CeilingLight, CeilingLightPro, Humidifier, SmartFan, BatteryCirculatorFan:
+		on := status.Power.ToLower() == "on"
+		accessory.On = &on
+	case RelaySwitch1PM, RelaySwitch1:
+		on := status.SwitchStatus == 1
+		accessory.On = &on
+	}
+
+	switch status.Type {
+	case ColorBulb, StripLight, CeilingLight, CeilingLightPro:
+		if brightness, err := status.Brightness.Int(); err == nil {
+			accessory.Brightness = &brightness
+		}
+	}
+
+	switch status.Type {
+	case Meter, MeterPlus, MeterPlusJP, MeterPlusUS, WoIOSensor, MeterPro, MeterProCO2, Hub2, Hub3, Humidifier:
+		temperature := toCelsius(status.Temperature, status.Scale)
+		humidity := status.Humidity
+		accessory.Temperature = &temperature
+		accessory.Humidity = &humidity
+	}
+
+	switch status.Type {
+	case Curtain, BlindTilt, RollerShade:
+		position := status.SlidePosition
+		accessory.Position = &position
+	case Lock, LockPro:
+		locked := status.LockState.Is(Locked)
+		accessory.Locked = &locked
+	case MotionSensor:
+		detected := status.IsMoveDetected
+		accessory.MotionDetected = &detected
+	case ContactSensor:
+		if status.OpenState != "" {
+			open := status.OpenState != ContactClose
+			accessory.ContactOpen = &open
+		}
+		detected := status.IsMoveDetected
+		accessory.MotionDetected = &detected
+	}
+
+	if status.BatteryReported {
+		battery := status.Battery
+		accessory.Battery = &battery
+	}
+
+	return accessory
+}
+
 // Command is an interface which represents Commands for devices to be used (*Client).Device().Command() method.
 type Command interface {
 	Request() DeviceCommandRequest
 }
 
+// DeviceCommandRequest is a request body of the device control API.
+// The Commands returned by the constructors in this package are DeviceCommandRequest,
+// and its JSON encoding is exactly what is sent to the API, so that any Command can be
+// serialized with json.Marshal(cmd.Request()) and restored with CommandFromJSON.
 type DeviceCommandRequest struct {
 	Command     string `json:"command"`
 	Parameter   string `json:"parameter,omitempty"`
 	CommandType string `json:"commandType,omitempty"`
 }
 
-type deviceCommandResponse struct {
-	StatusCode int    `json:"statusCode"`
-	Message    string `json:"message"`
-}
+// CommandFromJSON returns a Command decoded from given JSON, which is typically
+// encoded from the return value of (Command).Request(), e.g. to persist commands
+// and replay them later.
+// An error is returned when the JSON has unknown fields or has no command name.
+func CommandFromJSON(b []byte) (Command, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var req DeviceCommandRequest
+	if err := dec.Decode(&req); err != nil {
+		return nil, fmt.Errorf("decoding command: %w", err)
+	}
+
+	if req.Command == "" {
+		return nil, errors.New("command must not be empty")
+	}
+
+	return req, nil
+}
+
+type deviceCommandResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+func (svc *DeviceService) Command(ctx context.Context, id string, cmd Command) error {
+	response, err := svc.command(ctx, id, cmd)
+	if err != nil {
+		return err
+	}
+
+	return response.err()
+}
+
+// command sends the command as same as Command, but returns the decoded response
+// without interpreting its status code so that callers can read the body.
+func (svc *DeviceService) command(ctx context.Context, id string, cmd Command) (*deviceCommandResponse, error) {
+	if err := svc.lookup(ctx, id); err != nil {
+		return nil, err
+	}
+
+	path := "/v1.1/devices/" + id + "/commands"
+
+	resp, err := svc.c.post(ctx, path, cmd.Request())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	var response deviceCommandResponse
+	if err := resp.DecodeJSON(&response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (response deviceCommandResponse) err() error {
+	switch response.StatusCode {
+	case 151:
+		return errors.New("device type error")
+	case 152:
+		return errors.New("device not found")
+	case 160:
+		return errors.New("command is not supported")
+	case 161:
+		return errors.New("device is offline")
+	case 171:
+		return errors.New("hub device is offline")
+	case 190:
+		return errors.New("device internal error due to device states not synchronizeed with server or command format is invalid")
+	}
+
+	return nil
+}
+
+// ErrNotVerified is returned by SendAndVerify when the device status does not pass
+// the check within the timeout.
+var ErrNotVerified = errors.New("device status is not verified")
+
+// SendAndVerify sends the command to the device, then polls the device status until
+// check returns true for it. The polling interval starts from 100ms and doubles up
+// to 2s. When check does not pass within timeout, an error wrapping ErrNotVerified
+// is returned. The timeout is only applied to the polling, not to sending the command.
+func (svc *DeviceService) SendAndVerify(ctx context.Context, id string, cmd Command, check func(DeviceStatus) bool, timeout time.Duration) error {
+	if err := svc.Command(ctx, id, cmd); err != nil {
+		return err
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// notVerified returns the error when the polling context is done, which is
+	// caused by either the timeout or the cancellation of the given ctx.
+	notVerified := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s in %s", ErrNotVerified, id, timeout)
+	}
+
+	const maxInterval = 2 * time.Second
+	interval := 100 * time.Millisecond
+
+	for {
+		status, err := svc.Status(pollCtx, id)
+		if err != nil {
+			if pollCtx.Err() != nil {
+				return notVerified()
+			}
+			return err
+		}
+
+		if check(status) {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-pollCtx.Done():
+			timer.Stop()
+			return notVerified()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// UnlockTemporarily unlocks the Lock device, waits for d, then locks it again.
+// When ctx is done while waiting, the device is locked immediately so that it is
+// not left unlocked, and ctx.Err() is returned together with the error of locking, if any.
+func (svc *DeviceService) UnlockTemporarily(ctx context.Context, id string, d time.Duration) error {
+	if err := svc.Command(ctx, id, UnlockCommand()); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-func (svc *DeviceService) Command(ctx context.Context, id string, cmd Command) error {
-	path := "/v1.1/devices/" + id + "/commands"
+	select {
+	case <-ctx.Done():
+		// ctx cannot be used for locking anymore
+		return errors.Join(ctx.Err(), svc.Command(context.Background(), id, LockCommand()))
+	case <-timer.C:
+	}
 
-	resp, err := svc.c.post(ctx, path, cmd.Request())
+	return svc.Command(ctx, id, LockCommand())
+}
+
+// ToggleAndConfirm sends ToggleCommand to the device, e.g. Plug Mini, then polls the
+// device status as same as SendAndVerify until the power state is flipped from the
+// one before toggling. The confirmed power state, PowerOn or PowerOff, is returned.
+func (svc *DeviceService) ToggleAndConfirm(ctx context.Context, id string, timeout time.Duration) (PowerState, error) {
+	before, err := svc.Status(ctx, id)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer resp.Close()
 
-	var response deviceCommandResponse
-	if err := resp.DecodeJSON(&response); err != nil {
-		return err
+	want := PowerOn
+	if strings.EqualFold(string(before.Power), string(PowerOn)) {
+		want = PowerOff
 	}
 
-	switch response.StatusCode {
-	case 151:
-		return errors.New("device type error")
-	case 152:
-		return errors.New("device not found")
-	case 160:
-		return errors.New("command is not supported")
-	case 161:
-		return errors.New("device is offline")
-	case 171:
-		return errors.New("hub device is offline")
-	case 190:
-		return errors.New("device internal error due to device states not synchronizeed with server or command format is invalid")
+	check := func(status DeviceStatus) bool {
+		return strings.EqualFold(string(status.Power), string(want))
+	}
+	if err := svc.SendAndVerify(ctx, id, ToggleCommand(), check, timeout); err != nil {
+		return "", err
 	}
 
-	return nil
+	return want, nil
 }
 
 func (req DeviceCommandRequest) Request() DeviceCommandRequest {
@@ -340,7 +1381,8 @@ func (req DeviceCommandRequest) Request() DeviceCommandRequest {
 }
 
 // TurnOnCommand returns a new Command which turns on Bot, Plug, Curtain, Humidifier, or so on.
-// For curtain devices, turn on is equivalent to set position to 0.
+// For curtain devices, turn on is equivalent to set position to 0, which means opened.
+// The position is calibrated on the device, so this does not depend on the open direction.
 func TurnOnCommand() Command {
 	return DeviceCommandRequest{
 		Command:     "turnOn",
@@ -350,7 +1392,8 @@ func TurnOnCommand() Command {
 }
 
 // TurnOffCommand returns a nw Command which turns off Bot, plug, Curtain, Humidifier, or so on.
-// For curtain devices, turn off is equivalent to set position to 100.
+// For curtain devices, turn off is equivalent to set position to 100, which means closed.
+// The position is calibrated on the device, so this does not depend on the open direction.
 func TurnOffCommand() Command {
 	return DeviceCommandRequest{
 		Command:     "turnOff",
@@ -385,6 +1428,19 @@ const (
 	SilentMode
 )
 
+// String returns the mode as the API parameter, "ff" for DefaultMode, "0" for
+// PerformanceMode, and "1" for SilentMode (called quiet mode for Curtain 3).
+func (mode SetPositionMode) String() string {
+	switch mode {
+	case PerformanceMode:
+		return "0"
+	case SilentMode:
+		return "1"
+	default:
+		return "ff"
+	}
+}
+
 // SetPositionCommand returns a new Command which sets curtain devices' position.
 // The third argument `position` can be take 0 - 100 value, 0 means opened
 // and 100 means closed. The position value will be treated as 0 if the given
@@ -396,26 +1452,92 @@ func SetPosition(index int, mode SetPositionMode, position int) Command {
 		position = 100
 	}
 
-	var parameter string
+	return DeviceCommandRequest{
+		Command:     "setPosition",
+		Parameter:   fmt.Sprintf("%d,%s,%d", index, mode, position),
+		CommandType: "command",
+	}
+}
 
-	parameter += strconv.Itoa(index) + ","
+// CurtainSetPositionCommand returns a new Command which sets curtain devices' position
+// as same as SetPosition, but returns an error for invalid arguments instead of
+// correcting them.
+// index is the index of the curtain in the group, 0 for non-grouped curtains.
+// mode is DefaultMode, PerformanceMode, or SilentMode, which are sent as "ff", "0",
+// and "1" respectively.
+// position can be take 0 - 100 value, 0 means opened and 100 means closed.
+func CurtainSetPositionCommand(index int, mode SetPositionMode, position int) (Command, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("index must not be negative but %d", index)
+	}
 
 	switch mode {
-	case PerformanceMode, SilentMode:
-		parameter += strconv.Itoa(int(mode))
+	case DefaultMode, PerformanceMode, SilentMode:
 	default:
-		parameter += "ff"
+		return nil, fmt.Errorf("unknown set position mode: %d", int(mode))
+	}
+
+	if position < 0 || 100 < position {
+		return nil, fmt.Errorf("position must be 0 to 100 but %d", position)
+	}
+
+	return SetPosition(index, mode, position), nil
+}
+
+// RollerShadeSetPositionCommand returns a new Command which sets roller shade devices' position.
+// The position can be take 0 - 100 value, 0 means opened and 100 means closed. The position value
+// will be treated as 0 if the given value is less than 0, or treated as 100 if the given value
+// is over 100.
+func RollerShadeSetPositionCommand(position int) Command {
+	if position < 0 {
+		position = 0
+	} else if 100 < position {
+		position = 100
 	}
-	parameter += ","
-	parameter += strconv.Itoa(position)
 
 	return DeviceCommandRequest{
 		Command:     "setPosition",
-		Parameter:   parameter,
+		Parameter:   strconv.Itoa(position),
+		CommandType: "command",
+	}
+}
+
+// AirPurifierMode represents a mode of Air Purifier devices.
+type AirPurifierMode int
+
+const (
+	// AirPurifierNormalMode runs the fan at the fan gear set by AirPurifierSetFanGearCommand.
+	AirPurifierNormalMode AirPurifierMode = 1
+	AirPurifierAutoMode   AirPurifierMode = 2
+	AirPurifierSleepMode  AirPurifierMode = 3
+	AirPurifierPetMode    AirPurifierMode = 4
+)
+
+// SetAirPurifierModeCommand returns a new Command which sets the mode of Air Purifier
+// devices. To set the fan gear together with the normal mode, use AirPurifierSetFanGearCommand.
+func SetAirPurifierModeCommand(mode AirPurifierMode) Command {
+	return DeviceCommandRequest{
+		Command:     "setMode",
+		Parameter:   fmt.Sprintf(`{"mode":%d}`, mode),
 		CommandType: "command",
 	}
 }
 
+// AirPurifierSetFanGearCommand returns a new Command which sets the fan speed of Air
+// Purifier devices. The device is switched to the normal mode, as the fan gear is
+// only effective in the mode. gear can take 1 - 3 value.
+func AirPurifierSetFanGearCommand(gear int) (Command, error) {
+	if gear < 1 || 3 < gear {
+		return nil, fmt.Errorf("fan gear must be 1 to 3 but %d", gear)
+	}
+
+	return DeviceCommandRequest{
+		Command:     "setMode",
+		Parameter:   fmt.Sprintf(`{"mode":1,"fanGear":%d}`, gear),
+		CommandType: "command",
+	}, nil
+}
+
 // LockCommand returns a new Command which rotates the Lock device to locked position.
 func LockCommand() Command {
 	return DeviceCommandRequest{
@@ -434,6 +1556,16 @@ func UnlockCommand() Command {
 	}
 }
 
+// DeadboltCommand returns a new Command which retracts the deadbolt of the Lock Pro
+// device while leaving the latch bolt locked.
+func DeadboltCommand() Command {
+	return DeviceCommandRequest{
+		Command:     "deadbolt",
+		Parameter:   "default",
+		CommandType: "command",
+	}
+}
+
 type HumidifierMode int
 
 const (
@@ -462,6 +1594,35 @@ func SetModeCommand(mode HumidifierMode) Command {
 	}
 }
 
+// EvaporativeHumidifierMode represents a mode for Evaporative Humidifier.
+type EvaporativeHumidifierMode int
+
+const (
+	EvaporativeHumidifierLevel4Mode   EvaporativeHumidifierMode = 1
+	EvaporativeHumidifierLevel3Mode   EvaporativeHumidifierMode = 2
+	EvaporativeHumidifierLevel2Mode   EvaporativeHumidifierMode = 3
+	EvaporativeHumidifierLevel1Mode   EvaporativeHumidifierMode = 4
+	EvaporativeHumidifierHumidityMode EvaporativeHumidifierMode = 5
+	EvaporativeHumidifierSleepMode    EvaporativeHumidifierMode = 6
+	EvaporativeHumidifierAutoMode     EvaporativeHumidifierMode = 7
+	EvaporativeHumidifierDryingMode   EvaporativeHumidifierMode = 8
+)
+
+// SetEvaporativeHumidifierModeCommand returns a new Command which sets a mode and
+// target humidity for Evaporative Humidifier. targetHumidity can take 0 - 100 value,
+// otherwise an error is returned.
+func SetEvaporativeHumidifierModeCommand(mode EvaporativeHumidifierMode, targetHumidity int) (Command, error) {
+	if targetHumidity < 0 || 100 < targetHumidity {
+		return nil, fmt.Errorf("target humidity must be 0 to 100 but %d", targetHumidity)
+	}
+
+	return DeviceCommandRequest{
+		Command:     "setMode",
+		Parameter:   fmt.Sprintf(`{"mode":%d,"targetHumidity":%d}`, mode, targetHumidity),
+		CommandType: "command",
+	}, nil
+}
+
 type SmartFanMode int
 
 const (
@@ -478,6 +1639,76 @@ func SetAllStatusCommand(power PowerState, fanMode SmartFanMode, fanSpeed, shake
 	}
 }
 
+// NightLightMode represents a night light mode for Battery Circulator Fan.
+type NightLightMode string
+
+const (
+	NightLightOff   NightLightMode = "off"
+	NightLightMode1 NightLightMode = "1"
+	NightLightMode2 NightLightMode = "2"
+)
+
+// SetNightLightModeCommand returns a new Command which sets the night light mode of Battery Circulator Fan.
+func SetNightLightModeCommand(mode NightLightMode) Command {
+	return DeviceCommandRequest{
+		Command:     "setNightLightMode",
+		Parameter:   string(mode),
+		CommandType: "command",
+	}
+}
+
+// FanWindMode represents a wind mode for Battery Circulator Fan.
+type FanWindMode string
+
+const (
+	DirectWindMode  FanWindMode = "direct"
+	NaturalWindMode FanWindMode = "natural"
+	SleepWindMode   FanWindMode = "sleep"
+	BabyWindMode    FanWindMode = "baby"
+)
+
+// SetWindModeCommand returns a new Command which sets the wind mode of Battery Circulator Fan.
+func SetWindModeCommand(mode FanWindMode) Command {
+	return DeviceCommandRequest{
+		Command:     "setWindMode",
+		Parameter:   string(mode),
+		CommandType: "command",
+	}
+}
+
+// SetWindSpeedCommand returns a new Command which sets the wind speed of Battery Circulator Fan.
+// The speed can be take 1 - 100 value, otherwise an error is returned.
+func SetWindSpeedCommand(speed int) (Command, error) {
+	if speed < 1 || 100 < speed {
+		return nil, fmt.Errorf("wind speed must be 1 to 100 but %d", speed)
+	}
+
+	return DeviceCommandRequest{
+		Command:     "setWindSpeed",
+		Parameter:   strconv.Itoa(speed),
+		CommandType: "command",
+	}, nil
+}
+
+// RelaySwitchMode represents a mode for relay switch devices.
+type RelaySwitchMode int
+
+const (
+	RelayToggleMode    RelaySwitchMode = 0
+	RelayEdgeMode      RelaySwitchMode = 1
+	RelayDetachedMode  RelaySwitchMode = 2
+	RelayMomentaryMode RelaySwitchMode = 3
+)
+
+// SetRelaySwitchModeCommand returns a new Command which sets the switch mode of Relay Switch 1PM or Relay Switch 1.
+func SetRelaySwitchModeCommand(mode RelaySwitchMode) Command {
+	return DeviceCommandRequest{
+		Command:     "setMode",
+		Parameter:   strconv.Itoa(int(mode)),
+		CommandType: "command",
+	}
+}
+
 // ToggleCommand returns a new Command which toggles state of color bulb, strip light or plug mini.
 func ToggleCommand() Command {
 	return DeviceCommandRequest{
@@ -487,26 +1718,68 @@ func ToggleCommand() Command {
 	}
 }
 
+const (
+	// MinBrightness is the minimum value accepted by SetBrightnessCommand.
+	MinBrightness = 1
+	// MaxBrightness is the maximum value accepted by SetBrightnessCommand.
+	MaxBrightness = 100
+)
+
 // SetBrightnessCommand returns a new Command which set brightness of color bulb, strip light, or ceiling ligths.
-func SetBrightnessCommand(brightness int) Command {
+// brightness must be MinBrightness - MaxBrightness (1 - 100).
+func SetBrightnessCommand(brightness int) (Command, error) {
+	if brightness < MinBrightness || MaxBrightness < brightness {
+		return nil, fmt.Errorf("brightness must be %d to %d but %d", MinBrightness, MaxBrightness, brightness)
+	}
+
 	return DeviceCommandRequest{
 		Command:     "setBrightness",
 		Parameter:   strconv.Itoa(brightness),
 		CommandType: "command",
-	}
+	}, nil
 }
 
 // SetColorCommand returns a new Command which set RGB color value of color bulb or strip light.
-func SetColorCommand(r, g, b int) Command {
+// Each of r, g, and b must be 0 - 255.
+func SetColorCommand(r, g, b int) (Command, error) {
+	for _, channel := range []struct {
+		name  string
+		value int
+	}{
+		{name: "red", value: r},
+		{name: "green", value: g},
+		{name: "blue", value: b},
+	} {
+		if channel.value < 0 || 255 < channel.value {
+			return nil, fmt.Errorf("%s value must be 0 to 255 but %d", channel.name, channel.value)
+		}
+	}
+
 	return DeviceCommandRequest{
 		Command:     "setColor",
 		Parameter:   fmt.Sprintf("%d:%d:%d", r, g, b),
 		CommandType: "command",
-	}
+	}, nil
 }
 
+const (
+	// MinColorTemperature is the minimum value accepted by SetColorTemperatureCommand.
+	MinColorTemperature = 2700
+	// MaxColorTemperature is the maximum value accepted by SetColorTemperatureCommand.
+	MaxColorTemperature = 6500
+)
+
 // SetColorTemperatureCommand returns a new Command which set color temperature of color bulb or ceiling lights.
-func SetColorTemperatureCommand(temperature int) Command {
+// temperature must be MinColorTemperature - MaxColorTemperature (2700 - 6500).
+func SetColorTemperatureCommand(temperature int) (Command, error) {
+	if temperature < MinColorTemperature || MaxColorTemperature < temperature {
+		return nil, fmt.Errorf("color temperature must be %d to %d but %d", MinColorTemperature, MaxColorTemperature, temperature)
+	}
+
+	return setColorTemperatureCommand(temperature), nil
+}
+
+func setColorTemperatureCommand(temperature int) Command {
 	return DeviceCommandRequest{
 		Command:     "setColorTemperature",
 		Parameter:   strconv.Itoa(temperature),
@@ -514,7 +1787,25 @@ func SetColorTemperatureCommand(temperature int) Command {
 	}
 }
 
+// ColorPreset represents a named color temperature preset of ceiling lights.
+// The value is the color temperature in Kelvin.
+type ColorPreset int
+
+const (
+	WarmColorPreset     ColorPreset = 2700
+	NeutralColorPreset  ColorPreset = 4000
+	CoolColorPreset     ColorPreset = 5000
+	DaylightColorPreset ColorPreset = 6500
+)
+
+// CeilingSetPresetCommand returns a new Command which set color temperature of ceiling
+// lights to given preset.
+func CeilingSetPresetCommand(preset ColorPreset) Command {
+	return setColorTemperatureCommand(int(preset))
+}
+
 // StartCommand returns a new Command which starts vacuuming.
+// This command is supported by Robot Vacuum Cleaner S1, S1 Plus and K10+ (WoSweeperMini).
 func StartCommand() Command {
 	return DeviceCommandRequest{
 		Command:     "start",
@@ -524,6 +1815,7 @@ func StartCommand() Command {
 }
 
 // StopCommand returns a new Command which stops vacuuming.
+// This command is supported by Robot Vacuum Cleaner S1, S1 Plus and K10+ (WoSweeperMini).
 func StopCommand() Command {
 	return DeviceCommandRequest{
 		Command:     "stop",
@@ -533,6 +1825,7 @@ func StopCommand() Command {
 }
 
 // DockCommand returns a new Command which returns robot vacuum cleaner to charging dock.
+// This command is supported by Robot Vacuum Cleaner S1, S1 Plus and K10+ (WoSweeperMini).
 func DockCommand() Command {
 	return DeviceCommandRequest{
 		Command:     "dock",
@@ -551,6 +1844,7 @@ const (
 )
 
 // PowLevelCommand returns a new Command which sets suction power level of robot vacuum cleaner.
+// This command is supported by Robot Vacuum Cleaner S1, S1 Plus and K10+ (WoSweeperMini).
 func PowLevelCommand(level VacuumPowerLevel) Command {
 	return DeviceCommandRequest{
 		Command:     "PowLevel",
@@ -559,6 +1853,67 @@ func PowLevelCommand(level VacuumPowerLevel) Command {
 	}
 }
 
+// CleanAction represents a cleaning action for robot vacuum cleaners which support
+// startClean command, such as K10+ Pro and S10.
+type CleanAction string
+
+const (
+	Sweep    CleanAction = "sweep"
+	SweepMop CleanAction = "sweep_mop"
+)
+
+// StartCleanCommand returns a new Command which starts cleaning with given action
+// for Robot Vacuum Cleaner K10+ Pro and S10.
+// fanLevel is a suction power level from 1 to 4, otherwise an error is returned, and
+// times is the number of cleaning cycles.
+func StartCleanCommand(action CleanAction, fanLevel, times int) (Command, error) {
+	if fanLevel < 1 || 4 < fanLevel {
+		return nil, fmt.Errorf("fan level must be 1 to 4 but %d", fanLevel)
+	}
+
+	return DeviceCommandRequest{
+		Command:     "startClean",
+		Parameter:   fmt.Sprintf(`{"action":"%s","param":{"fanLevel":%d,"times":%d}}`, action, fanLevel, times),
+		CommandType: "command",
+	}, nil
+}
+
+// SelfCleanCommand returns a new Command which starts self-cleaning of S10's base station.
+// mode can take 1 (wash the mop), 2 (dry itself), or 3 (terminate), otherwise an error is returned.
+func SelfCleanCommand(mode int) (Command, error) {
+	if mode < 1 || 3 < mode {
+		return nil, fmt.Errorf("self clean mode must be 1 to 3 but %d", mode)
+	}
+
+	return DeviceCommandRequest{
+		Command:     "selfClean",
+		Parameter:   strconv.Itoa(mode),
+		CommandType: "command",
+	}, nil
+}
+
+// SetVolumeCommand returns a new Command which sets the voice volume of Robot Vacuum Cleaner
+// K10+ Pro and S10. volume can take 0 - 100 value, which is not checked; use
+// SetVacuumVolumeCommand to validate it.
+func SetVolumeCommand(volume int) Command {
+	return DeviceCommandRequest{
+		Command:     "setVolume",
+		Parameter:   strconv.Itoa(volume),
+		CommandType: "command",
+	}
+}
+
+// SetVacuumVolumeCommand returns a new Command which sets the voice volume of Robot
+// Vacuum Cleaner as same as SetVolumeCommand, but returns an error when volume is
+// out of 0 - 100.
+func SetVacuumVolumeCommand(volume int) (Command, error) {
+	if volume < 0 || 100 < volume {
+		return nil, fmt.Errorf("volume must be 0 to 100 but %d", volume)
+	}
+
+	return SetVolumeCommand(volume), nil
+}
+
 type createKeyCommandParameters struct {
 	Name     string       `json:"name"`
 	Type     PasscodeType `json:"type"`
@@ -602,16 +1957,121 @@ func CreateKeyCommand(name string, typ PasscodeType, password string, start, end
 	}, nil
 }
 
+// CreateKeyParams is a set of parameters for CreateKey.
+// See CreateKeyCommand for the details of each parameter.
+type CreateKeyParams struct {
+	Name     string
+	Type     PasscodeType
+	Password string
+	Start    time.Time
+	End      time.Time
+}
+
+// ErrKeyNotReturned is returned by CreateKey when the key is created but the response
+// does not contain the created key. The API documents that the created passcode is
+// only reported through webhook, so this is the usual result.
+var ErrKeyNotReturned = errors.New("created key is not returned in the response")
+
+// CreateKey creates a new key for Lock devices as same as sending CreateKeyCommand,
+// and returns the created key decoded from the response body. When the response
+// body has no key ID, an error wrapping ErrKeyNotReturned is returned and the
+// created key needs to be got through webhook.
+func (svc *DeviceService) CreateKey(ctx context.Context, id string, params CreateKeyParams) (*KeyListItem, error) {
+	cmd, err := CreateKeyCommand(params.Name, params.Type, params.Password, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := svc.command(ctx, id, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err := response.err(); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		KeyListItem
+		ID *int `json:"id"`
+	}
+	if len(response.Body) > 0 {
+		if err := json.Unmarshal(response.Body, &body); err != nil {
+			return nil, fmt.Errorf("decoding created key: %w", err)
+		}
+	}
+
+	if body.ID == nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotReturned, params.Name)
+	}
+
+	key := body.KeyListItem
+	key.ID = *body.ID
+
+	return &key, nil
+}
+
 // DeleteKeyCommand returns a new Command which deletes a key from Lock devices.
 func DeleteKeyCommand(id int) Command {
 	return DeviceCommandRequest{
 		Command:     "deleteKey",
-		Parameter:   fmt.Sprintf(`{"id": %d}`, id),
+		Parameter:   fmt.Sprintf(`{"id":%d}`, id),
 		CommandType: "command",
 	}
 }
 
+// ErrKeyNotFound is returned by DeleteKey when the key to be deleted does not exist.
+var ErrKeyNotFound = errors.New("key not found")
+
+// isKeyNotFoundMessage reports whether the message of the API response says the key
+// does not exist.
+func isKeyNotFoundMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "key") &&
+		(strings.Contains(message, "not exist") || strings.Contains(message, "not found"))
+}
+
+// DeleteKey deletes a key from Lock devices as same as sending DeleteKeyCommand,
+// but interprets the response. ErrKeyNotFound is wrapped in the returned error when
+// the API rejects the command because the key with given keyID does not exist.
+func (svc *DeviceService) DeleteKey(ctx context.Context, id string, keyID int) error {
+	response, err := svc.command(ctx, id, DeleteKeyCommand(keyID))
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode == 100 {
+		return nil
+	}
+
+	// 190 is also returned for other errors, e.g. invalid command format or device
+	// internal errors, so only the message saying the key is missing is interpreted
+	if response.StatusCode == 190 && isKeyNotFoundMessage(response.Message) {
+		return fmt.Errorf("%w: id %d: %s", ErrKeyNotFound, keyID, response.Message)
+	}
+
+	if err := response.err(); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("unknown error %d from delete key command: %s", response.StatusCode, response.Message)
+}
+
+// CustomCommand returns a new Command which triggers the button named name of
+// DIY infrared devices. DIY (learned) devices accept only the exact names of the
+// buttons learned in the SwitchBot app with commandType "customize", while standard
+// infrared devices are controlled by the constructors such as TurnOnCommand or
+// SetChannelCommand which use commandType "command".
+// An error is returned when name is empty.
+func CustomCommand(name string) (Command, error) {
+	if name == "" {
+		return nil, errors.New("button name must not be empty")
+	}
+
+	return ButtonPushCommand(name), nil
+}
+
 // ButtonPushCommand returns a new Command which triggers button push.
+// This is same as CustomCommand except that name is not validated.
 func ButtonPushCommand(name string) Command {
 	return DeviceCommandRequest{
 		Command:     name,
@@ -881,3 +2341,117 @@ func LightBrightnessDownCommand() Command {
 		CommandType: "command",
 	}
 }
+
+// InfraredCommands is a set of the standard commands supported by all the virtual
+// infrared remote devices.
+type InfraredCommands struct{}
+
+// TurnOn returns TurnOnCommand.
+func (InfraredCommands) TurnOn() Command { return TurnOnCommand() }
+
+// TurnOff returns TurnOffCommand.
+func (InfraredCommands) TurnOff() Command { return TurnOffCommand() }
+
+// AirConditionerCommands is a set of the standard commands for Air Conditioner,
+// e.g. AirConditionerCommands{}.SetAll(26, ACCool, ACAutoSpeed, PowerOn).
+type AirConditionerCommands struct {
+	InfraredCommands
+}
+
+// SetAll returns ACSetAllCommand.
+func (AirConditionerCommands) SetAll(temperature int, mode ACMode, fanSpeed ACFanSpeed, power PowerState) Command {
+	return ACSetAllCommand(temperature, mode, fanSpeed, power)
+}
+
+// TVCommands is a set of the standard commands for TV, IPTV/Streamer, and Set Top Box,
+// e.g. TVCommands{}.SetChannel(15).
+type TVCommands struct {
+	InfraredCommands
+}
+
+// SetChannel returns SetChannelCommand.
+func (TVCommands) SetChannel(channelNumber int) Command { return SetChannelCommand(channelNumber) }
+
+// VolumeAdd returns VolumeAddCommand.
+func (TVCommands) VolumeAdd() Command { return VolumeAddCommand() }
+
+// VolumeSub returns VolumeSubCommand.
+func (TVCommands) VolumeSub() Command { return VolumeSubCommand() }
+
+// ChannelAdd returns ChannelAddCommand.
+func (TVCommands) ChannelAdd() Command { return ChannelAddCommand() }
+
+// ChannelSub returns ChannelSubCommand.
+func (TVCommands) ChannelSub() Command { return ChannelSubCommand() }
+
+// DVDCommands is a set of the standard commands for DVD players, e.g. DVDCommands{}.Play().
+type DVDCommands struct {
+	InfraredCommands
+}
+
+// SetMute returns SetMuteCommand.
+func (DVDCommands) SetMute() Command { return SetMuteCommand() }
+
+// FastForward returns FastForwardCommand.
+func (DVDCommands) FastForward() Command { return FastForwardCommand() }
+
+// Rewind returns RewindCommand.
+func (DVDCommands) Rewind() Command { return RewindCommand() }
+
+// Next returns NextCommand.
+func (DVDCommands) Next() Command { return NextCommand() }
+
+// Previous returns PreviousCommand.
+func (DVDCommands) Previous() Command { return PreviousCommand() }
+
+// Pause returns PauseCommand.
+func (DVDCommands) Pause() Command { return PauseCommand() }
+
+// Play returns PlayCommand.
+func (DVDCommands) Play() Command { return PlayCommand() }
+
+// Stop returns StopPlayerCommand.
+func (DVDCommands) Stop() Command { return StopPlayerCommand() }
+
+// SpeakerCommands is a set of the standard commands for speakers, which supports
+// the commands for DVD players and volume controls.
+type SpeakerCommands struct {
+	DVDCommands
+}
+
+// VolumeAdd returns VolumeAddCommand.
+func (SpeakerCommands) VolumeAdd() Command { return VolumeAddCommand() }
+
+// VolumeSub returns VolumeSubCommand.
+func (SpeakerCommands) VolumeSub() Command { return VolumeSubCommand() }
+
+// FanCommands is a set of the standard commands for fans, e.g. FanCommands{}.Swing().
+type FanCommands struct {
+	InfraredCommands
+}
+
+// Swing returns FanSwingCommand.
+func (FanCommands) Swing() Command { return FanSwingCommand() }
+
+// Timer returns FanTimerCommand.
+func (FanCommands) Timer() Command { return FanTimerCommand() }
+
+// LowSpeed returns FanLowSpeedCommand.
+func (FanCommands) LowSpeed() Command { return FanLowSpeedCommand() }
+
+// MiddleSpeed returns FanMiddleSpeedCommand.
+func (FanCommands) MiddleSpeed() Command { return FanMiddleSpeedCommand() }
+
+// HighSpeed returns FanHighSpeedCommand.
+func (FanCommands) HighSpeed() Command { return FanHighSpeedCommand() }
+
+// LightCommands is a set of the standard commands for lights, e.g. LightCommands{}.BrightnessUp().
+type LightCommands struct {
+	InfraredCommands
+}
+
+// BrightnessUp returns LightBrightnessUpCommand.
+func (LightCommands) BrightnessUp() Command { return LightBrightnessUpCommand() }
+
+// BrightnessDown returns LightBrightnessDownCommand.
+func (LightCommands) BrightnessDown() Command { return LightBrightnessDownCommand() }
@@ -2,11 +2,14 @@ package switchbot_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -94,293 +97,2792 @@ func TestDevices(t *testing.T) {
 	})
 }
 
-func TestDeviceStatus(t *testing.T) {
-	// https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#switchbot-meter-example
-	t.Run("meter", func(t *testing.T) {
-		srv := httptest.NewServer(
-			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.URL.Path != "/v1.1/devices/C271111EC0AB/status" {
-					t.Fatalf("unexpected request path: %s", r.URL.Path)
+func TestDeviceListSummary(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceList": [
+            {"deviceId": "500291B269BE", "deviceName": "Living Room Humidifier", "deviceType": "Humidifier"},
+            {"deviceId": "C271111EC0AB", "deviceName": "Living Room Meter", "deviceType": "Meter"},
+            {"deviceId": "C271111EC0AC", "deviceName": "Bedroom Meter", "deviceType": "Meter"}
+        ],
+        "infraredRemoteList": [
+            {"deviceId": "02-202008110034-13", "deviceName": "Living Room TV", "remoteType": "TV"},
+            {"deviceId": "02-202008110034-14", "deviceName": "Bedroom TV", "remoteType": "TV"},
+            {"deviceId": "02-202007201626-70", "deviceName": "Living Room AC", "remoteType": "Air Conditioner"}
+        ]
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	physical, infrared, err := c.Device().ListSummary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantPhysical := map[switchbot.PhysicalDeviceType]int{
+		switchbot.Humidifier: 1,
+		switchbot.Meter:      2,
+	}
+	if diff := cmp.Diff(wantPhysical, physical); diff != "" {
+		t.Errorf("physical device summary mismatch (-want +got):\n%s", diff)
+	}
+
+	wantInfrared := map[switchbot.VirtualDeviceType]int{
+		switchbot.TV:             2,
+		switchbot.AirConditioner: 1,
+	}
+	if diff := cmp.Diff(wantInfrared, infrared); diff != "" {
+		t.Errorf("infrared device summary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeviceListPhysicalAndInfrared(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceList": [
+            {"deviceId": "C271111EC0AB", "deviceName": "Living Room Meter", "deviceType": "Meter", "hubDeviceId": "FA7310762361"}
+        ],
+        "infraredRemoteList": [
+            {"deviceId": "02-202008110034-13", "deviceName": "Living Room TV", "remoteType": "TV", "hubDeviceId": "FA7310762361"}
+        ]
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+	t.Run("physical", func(t *testing.T) {
+		devices, err := c.Device().ListPhysical(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []switchbot.Device{
+			{ID: "C271111EC0AB", Name: "Living Room Meter", Type: switchbot.Meter, Hub: "FA7310762361"},
+		}
+		if diff := cmp.Diff(want, devices); diff != "" {
+			t.Errorf("device list mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("infrared", func(t *testing.T) {
+		devices, err := c.Device().ListInfrared(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []switchbot.InfraredDevice{
+			{ID: "02-202008110034-13", Name: "Living Room TV", Type: switchbot.TV, Hub: "FA7310762361"},
+		}
+		if diff := cmp.Diff(want, devices); diff != "" {
+			t.Errorf("infrared device list mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestDeviceAvailableCommands(t *testing.T) {
+	t.Run("physical", func(t *testing.T) {
+		tests := []struct {
+			label  string
+			device switchbot.Device
+			want   []string
+		}{
+			{
+				label:  "bot",
+				device: switchbot.Device{ID: "CA3A5E4CB1D0", Type: switchbot.Bot},
+				want:   []string{"turnOn", "turnOff", "press"},
+			},
+			{
+				label:  "curtain",
+				device: switchbot.Device{ID: "E2F6032048AB", Type: switchbot.Curtain},
+				want:   []string{"turnOn", "turnOff", "setPosition"},
+			},
+			{
+				label:  "meter",
+				device: switchbot.Device{ID: "C271111EC0AB", Type: switchbot.Meter},
+				want:   nil,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				if diff := cmp.Diff(tt.want, tt.device.AvailableCommands()); diff != "" {
+					t.Errorf("available commands mismatch (-want +got):\n%s", diff)
 				}
+			})
+		}
+	})
 
-				w.WriteHeader(http.StatusOK)
+	t.Run("infrared", func(t *testing.T) {
+		tests := []struct {
+			label  string
+			device switchbot.InfraredDevice
+			want   []string
+		}{
+			{
+				label:  "tv",
+				device: switchbot.InfraredDevice{ID: "02-202008110034-13", Type: switchbot.TV},
+				want:   []string{"turnOn", "turnOff", "SetChannel", "volumeAdd", "volumeSub", "channelAdd", "channelSub"},
+			},
+			{
+				label:  "others",
+				device: switchbot.InfraredDevice{ID: "02-202008110034-14", Type: switchbot.Others},
+				want:   []string{"turnOn", "turnOff"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				if diff := cmp.Diff(tt.want, tt.device.AvailableCommands()); diff != "" {
+					t.Errorf("available commands mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+
+	t.Run("commands are accepted by constructors", func(t *testing.T) {
+		tv := switchbot.InfraredDevice{Type: switchbot.TV}
+		commands := map[string]bool{}
+		for _, name := range tv.AvailableCommands() {
+			commands[name] = true
+		}
+
+		for _, cmd := range []switchbot.Command{
+			switchbot.TVCommands{}.TurnOn(),
+			switchbot.TVCommands{}.SetChannel(1),
+			switchbot.TVCommands{}.VolumeAdd(),
+			switchbot.TVCommands{}.ChannelSub(),
+		} {
+			if name := cmd.Request().Command; !commands[name] {
+				t.Errorf("%s should be available for TV", name)
+			}
+		}
+	})
+}
+
+func TestDeviceGet(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceList": [
+            {"deviceId": "C271111EC0AB", "deviceName": "Living Room Meter", "deviceType": "Meter", "hubDeviceId": "FA7310762361"}
+        ],
+        "infraredRemoteList": [
+            {"deviceId": "02-202008110034-13", "deviceName": "Living Room TV", "remoteType": "TV", "hubDeviceId": "FA7310762361"}
+        ]
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+	t.Run("physical device", func(t *testing.T) {
+		device, infrared, err := c.Device().Get(context.Background(), "C271111EC0AB")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if infrared != nil {
+			t.Errorf("infrared device should be nil but %+v", infrared)
+		}
+		if device == nil || device.Name != "Living Room Meter" {
+			t.Errorf("unexpected device: %+v", device)
+		}
+	})
+
+	t.Run("infrared device", func(t *testing.T) {
+		device, infrared, err := c.Device().Get(context.Background(), "02-202008110034-13")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if device != nil {
+			t.Errorf("device should be nil but %+v", device)
+		}
+		if infrared == nil || infrared.Name != "Living Room TV" {
+			t.Errorf("unexpected infrared device: %+v", infrared)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, _, err := c.Device().Get(context.Background(), "UNKNOWN")
+		if !errors.Is(err, switchbot.ErrDeviceNotFound) {
+			t.Errorf("ErrDeviceNotFound is expected but got %v", err)
+		}
+	})
+}
+
+func TestDeviceByHub(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceList": [
+            {"deviceId": "C271111EC0AB", "deviceName": "Living Room Meter", "deviceType": "Meter", "hubDeviceId": "FA7310762361"},
+            {"deviceId": "E2F6032048AB", "deviceName": "Living Room Curtain", "deviceType": "Curtain", "hubDeviceId": "FA7310762361"},
+            {"deviceId": "C271111EC0AC", "deviceName": "Bedroom Meter", "deviceType": "Meter", "hubDeviceId": "FA7310762362"}
+        ],
+        "infraredRemoteList": []
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	got, err := c.Device().ByHub(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]switchbot.Device{
+		"FA7310762361": {
+			{ID: "C271111EC0AB", Name: "Living Room Meter", Type: switchbot.Meter, Hub: "FA7310762361"},
+			{ID: "E2F6032048AB", Name: "Living Room Curtain", Type: switchbot.Curtain, Hub: "FA7310762361"},
+		},
+		"FA7310762362": {
+			{ID: "C271111EC0AC", Name: "Bedroom Meter", Type: switchbot.Meter, Hub: "FA7310762362"},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("devices mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeviceGroups(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceList": [
+            {
+                "deviceId": "E2F6032048AB",
+                "deviceName": "Living Room Curtain",
+                "deviceType": "Curtain",
+                "hubDeviceId": "FA7310762361",
+                "curtainDevicesIds": ["E2F6032048AB", "CB8A8E2BC1A3"],
+                "calibrate": true,
+                "group": true,
+                "master": true,
+                "openDirection": "left",
+                "groupName": "Living Room"
+            },
+            {
+                "deviceId": "CB8A8E2BC1A3",
+                "deviceName": "Living Room Curtain 2",
+                "deviceType": "Curtain",
+                "hubDeviceId": "FA7310762361",
+                "curtainDevicesIds": ["E2F6032048AB", "CB8A8E2BC1A3"],
+                "calibrate": true,
+                "group": true,
+                "master": false,
+                "openDirection": "right",
+                "groupName": "Living Room"
+            },
+            {
+                "deviceId": "C271111EC0AB",
+                "deviceName": "Meter",
+                "deviceType": "Meter",
+                "hubDeviceId": "FA7310762361"
+            }
+        ],
+        "infraredRemoteList": []
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	got, err := c.Device().Groups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []switchbot.DeviceGroup{
+		{
+			Name: "Living Room",
+			Master: switchbot.Device{
+				ID:            "E2F6032048AB",
+				Name:          "Living Room Curtain",
+				Type:          switchbot.Curtain,
+				Hub:           "FA7310762361",
+				Curtains:      []string{"E2F6032048AB", "CB8A8E2BC1A3"},
+				IsCalibrated:  true,
+				IsGrouped:     true,
+				IsMaster:      true,
+				OpenDirection: "left",
+				GroupName:     "Living Room",
+			},
+			Members: []switchbot.Device{
+				{
+					ID:            "CB8A8E2BC1A3",
+					Name:          "Living Room Curtain 2",
+					Type:          switchbot.Curtain,
+					Hub:           "FA7310762361",
+					Curtains:      []string{"E2F6032048AB", "CB8A8E2BC1A3"},
+					IsCalibrated:  true,
+					IsGrouped:     true,
+					IsMaster:      false,
+					OpenDirection: "right",
+					GroupName:     "Living Room",
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("group mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeviceAutoRefresh(t *testing.T) {
+	var listCalls, statusCalls int
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1.1/devices":
+				listCalls++
 				w.Write([]byte(`{
     "statusCode": 100,
     "body": {
-        "deviceId": "C271111EC0AB",
-        "deviceType": "Meter",
-        "hubDeviceId": "FA7310762361",
-        "humidity": 52,
-        "temperature": 26.1
+        "deviceList": [
+            {"deviceId": "C271111EC0AB", "deviceName": "Living Room Meter", "deviceType": "Meter", "hubDeviceId": "FA7310762361"}
+        ],
+        "infraredRemoteList": []
     },
     "message": "success"
 }`))
-			}),
-		)
+			case "/v1.1/devices/C271111EC0AB/status":
+				statusCalls++
+				w.Write([]byte(`{"statusCode":100,"body":{"deviceId":"C271111EC0AB","deviceType":"Meter","hubDeviceId":"FA7310762361","humidity":52,"temperature":26.1},"message":"success"}`))
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+		}),
+	)
+	defer srv.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := switchbot.New("", "",
+		switchbot.WithEndpoint(srv.URL),
+		switchbot.WithAutoRefreshDevices(),
+		switchbot.WithClock(func() time.Time { return now }),
+	)
+
+	t.Run("refresh on cache miss", func(t *testing.T) {
+		status, err := c.Device().Status(context.Background(), "C271111EC0AB")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status.ID != "C271111EC0AB" {
+			t.Errorf("unexpected device id: %s", status.ID)
+		}
+		if listCalls != 1 {
+			t.Errorf("device list should be refreshed once but %d times", listCalls)
+		}
+	})
+
+	t.Run("cache hit", func(t *testing.T) {
+		if _, err := c.Device().Status(context.Background(), "C271111EC0AB"); err != nil {
+			t.Fatal(err)
+		}
+
+		if listCalls != 1 {
+			t.Errorf("device list should not be refreshed on cache hit but %d times", listCalls)
+		}
+		if statusCalls != 2 {
+			t.Errorf("status API should be called twice but %d times", statusCalls)
+		}
+	})
+
+	t.Run("not found within refresh interval", func(t *testing.T) {
+		err := c.Device().Command(context.Background(), "UNKNOWN", switchbot.TurnOnCommand())
+		if !errors.Is(err, switchbot.ErrDeviceNotFound) {
+			t.Errorf("ErrDeviceNotFound is expected but got %v", err)
+		}
+		if listCalls != 1 {
+			t.Errorf("device list should not be refreshed within the interval but %d times", listCalls)
+		}
+	})
+
+	t.Run("not found after refresh interval", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		for i := 0; i < 3; i++ {
+			err := c.Device().Command(context.Background(), "UNKNOWN", switchbot.TurnOnCommand())
+			if !errors.Is(err, switchbot.ErrDeviceNotFound) {
+				t.Errorf("ErrDeviceNotFound is expected but got %v", err)
+			}
+		}
+		if listCalls != 2 {
+			t.Errorf("device list should be refreshed once after the interval but %d times", listCalls)
+		}
+	})
+}
+
+func TestDeviceAutoRefreshConcurrent(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		listCalls int
+	)
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1.1/devices" {
+				mu.Lock()
+				listCalls++
+				mu.Unlock()
+
+				// keep the refresh running while the other lookups miss the cache
+				time.Sleep(50 * time.Millisecond)
+
+				w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceList": [
+            {"deviceId": "A", "deviceName": "Meter A", "deviceType": "Meter", "hubDeviceId": "FA7310762361"},
+            {"deviceId": "B", "deviceName": "Meter B", "deviceType": "Meter", "hubDeviceId": "FA7310762361"},
+            {"deviceId": "C", "deviceName": "Meter C", "deviceType": "Meter", "hubDeviceId": "FA7310762361"}
+        ],
+        "infraredRemoteList": []
+    },
+    "message": "success"
+}`))
+				return
+			}
+
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.1/devices/"), "/status")
+			fmt.Fprintf(w, `{"statusCode":100,"body":{"deviceId":%q,"deviceType":"Meter"},"message":"success"}`, id)
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL), switchbot.WithAutoRefreshDevices())
+
+	statuses, errs := c.Device().Statuses(context.Background(), []string{"A", "B", "C"}, 3)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(statuses) != 3 {
+		t.Errorf("statuses of all devices should be returned but %d", len(statuses))
+	}
+	if listCalls != 1 {
+		t.Errorf("device list should be refreshed once for concurrent cache misses but %d times", listCalls)
+	}
+}
+
+func TestDeviceStatus(t *testing.T) {
+	// https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#switchbot-meter-example
+	t.Run("meter", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1.1/devices/C271111EC0AB/status" {
+					t.Fatalf("unexpected request path: %s", r.URL.Path)
+				}
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceId": "C271111EC0AB",
+        "deviceType": "Meter",
+        "hubDeviceId": "FA7310762361",
+        "humidity": 52,
+        "temperature": 26.1
+    },
+    "message": "success"
+}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+		got, err := c.Device().Status(context.Background(), "C271111EC0AB")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := switchbot.DeviceStatus{
+			ID:          "C271111EC0AB",
+			Type:        switchbot.Meter,
+			Hub:         "FA7310762361",
+			Humidity:    52,
+			Temperature: 26.1,
+		}
+
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{}, switchbot.Mode{})); diff != "" {
+			t.Fatalf("status mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	// https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#switchbot-curtain-example
+	t.Run("curtain", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1.1/devices/E2F6032048AB/status" {
+					t.Fatalf("unexpected request path: %s", r.URL.Path)
+				}
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceId": "E2F6032048AB",
+        "deviceType": "Curtain",
+        "hubDeviceId": "FA7310762361",
+        "calibrate": true,
+        "group": false,
+        "moving": false,
+        "slidePosition": 0
+    },
+    "message": "success"
+}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+		got, err := c.Device().Status(context.Background(), "E2F6032048AB")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := switchbot.DeviceStatus{
+			ID:            "E2F6032048AB",
+			Type:          switchbot.Curtain,
+			Hub:           "FA7310762361",
+			IsCalibrated:  true,
+			IsGrouped:     false,
+			IsMoving:      false,
+			SlidePosition: 0,
+		}
+
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{}, switchbot.Mode{})); diff != "" {
+			t.Fatalf("status mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("outdoor meter", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1.1/devices/C2E7A1F23B6D/status" {
+					t.Fatalf("unexpected request path: %s", r.URL.Path)
+				}
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceId": "C2E7A1F23B6D",
+        "deviceType": "WoIOSensor",
+        "hubDeviceId": "FA7310762361",
+        "battery": 88,
+        "version": "V1.1",
+        "temperature": 61.7,
+        "humidity": 74,
+        "scale": "FAHRENHEIT"
+    },
+    "message": "success"
+}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+		got, err := c.Device().Status(context.Background(), "C2E7A1F23B6D")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := switchbot.DeviceStatus{
+			ID:              "C2E7A1F23B6D",
+			Type:            switchbot.WoIOSensor,
+			Hub:             "FA7310762361",
+			Battery:         88,
+			Version:         "V1.1",
+			Temperature:     61.7,
+			BatteryReported: true,
+			Humidity:        74,
+			Scale:           switchbot.Fahrenheit,
+		}
+
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{}, switchbot.Mode{})); diff != "" {
+			t.Fatalf("status mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestDeviceStatusExtra(t *testing.T) {
+	var status switchbot.DeviceStatus
+	if err := json.Unmarshal([]byte(`{ "deviceId": "C271111EC0AB", "deviceType": "Meter", "temperature": 26.1, "power": "on", "subSensor": { "temperature": 18.2 } }`), &status); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]json.RawMessage{
+		"subSensor": json.RawMessage(`{ "temperature": 18.2 }`),
+	}
+
+	if diff := cmp.Diff(want, status.Extra); diff != "" {
+		t.Fatalf("extra mismatch (-want +got):\n%s", diff)
+	}
+
+	if status.Temperature != 26.1 || status.Power != "on" {
+		t.Errorf("known fields must be decoded as before: %+v", status)
+	}
+}
+
+func TestDeviceStatusEnableCloudService(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+		want  bool
+	}{
+		{
+			label: "enabled",
+			body:  `{ "deviceId": "C271111EC0AB", "deviceType": "Meter", "hubDeviceId": "FA7310762361", "enableCloudService": true, "humidity": 52, "temperature": 26.1 }`,
+			want:  true,
+		},
+		{
+			label: "not reported",
+			body:  `{ "deviceId": "C271111EC0AB", "deviceType": "Meter", "hubDeviceId": "FA7310762361", "humidity": 52, "temperature": 26.1 }`,
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var status switchbot.DeviceStatus
+			if err := json.Unmarshal([]byte(tt.body), &status); err != nil {
+				t.Fatal(err)
+			}
+
+			if status.IsEnableCloudService != tt.want {
+				t.Errorf("unexpected enableCloudService: %t != %t", status.IsEnableCloudService, tt.want)
+			}
+			if _, ok := status.Extra["enableCloudService"]; ok {
+				t.Error("enableCloudService should not be in extra")
+			}
+		})
+	}
+}
+
+func TestDeviceStatusHasBattery(t *testing.T) {
+	tests := []struct {
+		label       string
+		body        string
+		wantHas     bool
+		wantBattery int
+	}{
+		{
+			label:       "present zero",
+			body:        `{ "deviceId": "C271111EC0AB", "deviceType": "Meter", "battery": 0 }`,
+			wantHas:     true,
+			wantBattery: 0,
+		},
+		{
+			label:       "present nonzero",
+			body:        `{ "deviceId": "C271111EC0AB", "deviceType": "Meter", "battery": 85 }`,
+			wantHas:     true,
+			wantBattery: 85,
+		},
+		{
+			label:       "absent",
+			body:        `{ "deviceId": "6055F930FF22", "deviceType": "Plug Mini (JP)", "power": "on" }`,
+			wantHas:     false,
+			wantBattery: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var status switchbot.DeviceStatus
+			if err := json.Unmarshal([]byte(tt.body), &status); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := status.HasBattery(); got != tt.wantHas {
+				t.Errorf("unexpected HasBattery: %t != %t", got, tt.wantHas)
+			}
+			if status.Battery != tt.wantBattery {
+				t.Errorf("unexpected battery: %d != %d", status.Battery, tt.wantBattery)
+			}
+		})
+	}
+}
+
+func TestDeviceStatusVacuumVolume(t *testing.T) {
+	var status switchbot.DeviceStatus
+	if err := json.Unmarshal([]byte(`{ "deviceId": "B0E9FE5A1C2D", "deviceType": "Robot Vacuum Cleaner S10", "workingStatus": "StandBy", "onlineStatus": "online", "battery": 100, "volume": 40 }`), &status); err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Volume != 40 {
+		t.Errorf("unexpected volume: %d != 40", status.Volume)
+	}
+}
+
+func TestDeviceStatusLockState(t *testing.T) {
+	tests := []struct {
+		label  string
+		body   string
+		want   switchbot.LockState
+		wantIs switchbot.LockState
+	}{
+		{
+			label:  "locked",
+			body:   `{ "deviceType": "Smart Lock", "lockState": "locked" }`,
+			want:   "locked",
+			wantIs: switchbot.Locked,
+		},
+		{
+			label:  "unlocked",
+			body:   `{ "deviceType": "Smart Lock", "lockState": "UNLOCKED" }`,
+			want:   "UNLOCKED",
+			wantIs: switchbot.Unlocked,
+		},
+		{
+			label:  "jammed",
+			body:   `{ "deviceType": "Smart Lock", "lockState": "jammed" }`,
+			want:   "jammed",
+			wantIs: switchbot.Jammed,
+		},
+		{
+			label:  "unknown",
+			body:   `{ "deviceType": "Smart Lock", "lockState": "somethingNew" }`,
+			want:   "somethingNew",
+			wantIs: switchbot.LockState("SOMETHINGNEW"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(fmt.Sprintf(`{
+    "statusCode": 100,
+    "body": %s,
+    "message": "success"
+}`, tt.body)))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+			got, err := c.Device().Status(context.Background(), "F7538E1ABCEB")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.LockState != tt.want {
+				t.Errorf("lock state should be kept as reported: %s != %s", got.LockState, tt.want)
+			}
+			if !got.LockState.Is(tt.wantIs) {
+				t.Errorf("lock state %s should be %s ignoring case", got.LockState, tt.wantIs)
+			}
+			if got.LockState.Is(switchbot.LatchBoltLocked) {
+				t.Errorf("lock state %s must not be %s", got.LockState, switchbot.LatchBoltLocked)
+			}
+		})
+	}
+}
+
+func TestDeviceStatusDoorState(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceId": "F7538E1ABCEB",
+        "deviceType": "Smart Lock Pro",
+        "hubDeviceId": "FA7310762361",
+        "battery": 90,
+        "version": "V1.2",
+        "lockState": "locked",
+        "doorState": "opened",
+        "calibrate": true
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	got, err := c.Device().Status(context.Background(), "F7538E1ABCEB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.DoorState != switchbot.DoorOpened {
+		t.Errorf("unexpected door state: %s != %s", got.DoorState, switchbot.DoorOpened)
+	}
+}
+
+func TestDeviceStatusTyped(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+		want  interface{}
+	}{
+		{
+			label: "meter",
+			body:  `{ "deviceId": "C271111EC0AB", "deviceType": "Meter", "hubDeviceId": "FA7310762361", "humidity": 52, "temperature": 26.1, "battery": 100, "version": "V2.7" }`,
+			want: &switchbot.MeterStatus{
+				ID:          "C271111EC0AB",
+				Type:        switchbot.Meter,
+				Hub:         "FA7310762361",
+				Temperature: 26.1,
+				Humidity:    52,
+				Battery:     100,
+				Version:     "V2.7",
+			},
+		},
+		{
+			label: "meter with scale",
+			body:  `{ "deviceId": "C271111EC0AC", "deviceType": "Meter", "hubDeviceId": "FA7310762361", "humidity": 52, "temperature": 79.0, "scale": "Fahrenheit", "battery": 100, "version": "V2.7" }`,
+			want: &switchbot.MeterStatus{
+				ID:          "C271111EC0AC",
+				Type:        switchbot.Meter,
+				Hub:         "FA7310762361",
+				Temperature: 79.0,
+				Humidity:    52,
+				Scale:       switchbot.Fahrenheit,
+				Battery:     100,
+				Version:     "V2.7",
+			},
+		},
+		{
+			label: "meter plus (US)",
+			body:  `{ "deviceId": "D5A4F00AB34C", "deviceType": "Meter Plus (US)", "hubDeviceId": "FA7310762361", "humidity": 48, "temperature": 72.1, "scale": "FAHRENHEIT", "battery": 90, "version": "V1.3" }`,
+			want: &switchbot.MeterPlusStatus{
+				ID:          "D5A4F00AB34C",
+				Type:        switchbot.MeterPlusUS,
+				Hub:         "FA7310762361",
+				Temperature: 72.1,
+				Humidity:    48,
+				Scale:       switchbot.Fahrenheit,
+				Battery:     90,
+				Version:     "V1.3",
+			},
+		},
+		{
+			label: "meter plus (JP)",
+			body:  `{ "deviceId": "D5A4F00AB34D", "deviceType": "Meter Plus (JP)", "hubDeviceId": "FA7310762361", "humidity": 48, "temperature": 22.3, "scale": "CELSIUS", "battery": 90, "version": "V1.3" }`,
+			want: &switchbot.MeterPlusStatus{
+				ID:          "D5A4F00AB34D",
+				Type:        switchbot.MeterPlusJP,
+				Hub:         "FA7310762361",
+				Temperature: 22.3,
+				Humidity:    48,
+				Scale:       switchbot.Celsius,
+				Battery:     90,
+				Version:     "V1.3",
+			},
+		},
+		{
+			label: "lock",
+			body:  `{ "deviceId": "F7538E1ABCEB", "deviceType": "Smart Lock", "hubDeviceId": "FA7310762361", "lockState": "locked", "doorState": "closed", "calibrate": true, "battery": 90, "version": "V1.2" }`,
+			want: &switchbot.LockStatus{
+				ID:           "F7538E1ABCEB",
+				Type:         switchbot.Lock,
+				Hub:          "FA7310762361",
+				LockState:    "locked",
+				DoorState:    switchbot.DoorClosed,
+				IsCalibrated: true,
+				Battery:      90,
+				Version:      "V1.2",
+			},
+		},
+		{
+			label: "hub 2",
+			body:  `{ "deviceId": "FA7310762361", "deviceType": "Hub 2", "hubDeviceId": "FA7310762361", "temperature": 24.5, "humidity": 41, "lightLevel": 12, "version": "V0.9" }`,
+			want: &switchbot.Hub2Status{
+				ID:          "FA7310762361",
+				Type:        switchbot.Hub2,
+				Hub:         "FA7310762361",
+				Temperature: 24.5,
+				Humidity:    41,
+				LightLevel:  12,
+				Version:     "V0.9",
+			},
+		},
+		{
+			label: "lock pro latch bolt locked",
+			body:  `{ "deviceId": "F7538E1ABCEC", "deviceType": "Smart Lock Pro", "hubDeviceId": "FA7310762361", "lockState": "latchBoltLocked", "doorState": "closed", "calibrate": true, "battery": 85, "version": "V1.0" }`,
+			want: &switchbot.LockStatus{
+				ID:           "F7538E1ABCEC",
+				Type:         switchbot.LockPro,
+				Hub:          "FA7310762361",
+				LockState:    "latchBoltLocked",
+				DoorState:    switchbot.DoorClosed,
+				IsCalibrated: true,
+				Battery:      85,
+				Version:      "V1.0",
+			},
+		},
+		{
+			label: "plug mini in use",
+			body:  `{ "deviceId": "6055F930FF22", "deviceType": "Plug Mini (JP)", "hubDeviceId": "FA7310762361", "power": "on", "voltage": 100.6, "weight": 45, "electricityOfDay": 12, "electricCurrent": 0.45, "version": "V1.4" }`,
+			want: &switchbot.PlugStatus{
+				ID:               "6055F930FF22",
+				Type:             switchbot.PlugMiniJP,
+				Hub:              "FA7310762361",
+				Power:            "on",
+				Voltage:          100.6,
+				Weight:           45,
+				ElectricityOfDay: 12,
+				ElectricCurrent:  0.45,
+				Version:          "V1.4",
+				InUse:            true,
+			},
+		},
+		{
+			label: "plug mini not in use",
+			body:  `{ "deviceId": "6055F930FF22", "deviceType": "Plug Mini (JP)", "hubDeviceId": "FA7310762361", "power": "on", "voltage": 100.6, "weight": 0, "electricityOfDay": 12, "electricCurrent": 0, "version": "V1.4" }`,
+			want: &switchbot.PlugStatus{
+				ID:               "6055F930FF22",
+				Type:             switchbot.PlugMiniJP,
+				Hub:              "FA7310762361",
+				Power:            "on",
+				Voltage:          100.6,
+				ElectricityOfDay: 12,
+				Version:          "V1.4",
+				InUse:            false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(fmt.Sprintf(`{
+    "statusCode": 100,
+    "body": %s,
+    "message": "success"
+}`, tt.body)))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+			got, err := c.Device().StatusTyped(context.Background(), "C271111EC0AB")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("status mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeviceStatusBotMode(t *testing.T) {
+	tests := []struct {
+		label       string
+		body        string
+		wantMode    switchbot.BotDeviceMode
+		wantCommand string
+	}{
+		{
+			label:       "press mode",
+			body:        `{ "deviceId": "CA3A5E4CB1D0", "deviceType": "Bot", "hubDeviceId": "FA7310762361", "power": "on", "battery": 90, "version": "V6.3", "deviceMode": "pressMode" }`,
+			wantMode:    switchbot.BotPressMode,
+			wantCommand: "press",
+		},
+		{
+			label:       "switch mode",
+			body:        `{ "deviceId": "CA3A5E4CB1D0", "deviceType": "Bot", "hubDeviceId": "FA7310762361", "power": "on", "battery": 90, "version": "V6.3", "deviceMode": "switchMode" }`,
+			wantMode:    switchbot.BotSwitchMode,
+			wantCommand: "turnOn",
+		},
+		{
+			label:       "customize mode",
+			body:        `{ "deviceId": "CA3A5E4CB1D0", "deviceType": "Bot", "hubDeviceId": "FA7310762361", "power": "on", "battery": 90, "version": "V6.3", "deviceMode": "customizeMode" }`,
+			wantMode:    switchbot.BotCustomizeMode,
+			wantCommand: "press",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(fmt.Sprintf(`{
+    "statusCode": 100,
+    "body": %s,
+    "message": "success"
+}`, tt.body)))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+			got, err := c.Device().StatusTyped(context.Background(), "CA3A5E4CB1D0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status, ok := got.(*switchbot.BotStatus)
+			if !ok {
+				t.Fatalf("status must be a bot status but %T", got)
+			}
+
+			if status.Mode != tt.wantMode {
+				t.Errorf("unexpected bot mode: %s != %s", status.Mode, tt.wantMode)
+			}
+
+			if got := status.PreferredOnCommand().Request().Command; got != tt.wantCommand {
+				t.Errorf("unexpected preferred on command: %s != %s", got, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestDeviceStatusRelaySwitch1PM(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceId": "7C2C67F3A6B4",
+        "deviceType": "Relay Switch 1PM",
+        "switchStatus": 1,
+        "voltage": 100.8,
+        "version": "V1.3",
+        "power": 12.4,
+        "usedElectricity": 310,
+        "electricCurrent": 0.12,
+        "mode": 1
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	got, err := c.Device().Status(context.Background(), "7C2C67F3A6B4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := switchbot.DeviceStatus{
+		ID:              "7C2C67F3A6B4",
+		Type:            switchbot.RelaySwitch1PM,
+		SwitchStatus:    1,
+		Voltage:         100.8,
+		Version:         "V1.3",
+		ElectricPower:   12.4,
+		UsedElectricity: 310,
+		ElectricCurrent: 0.12,
+	}
+	if err := json.Unmarshal([]byte(`1`), &want.Mode); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{}, switchbot.Mode{})); diff != "" {
+		t.Fatalf("status mismatch (-want +got):\n%s", diff)
+	}
+
+	if mode := got.RelaySwitchMode(); mode != switchbot.RelayEdgeMode {
+		t.Errorf("unexpected relay switch mode: %d != %d", mode, switchbot.RelayEdgeMode)
+	}
+}
+
+func TestDeviceStatusHumidifierMode(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+		want  switchbot.HumidifierMode
+	}{
+		{
+			label: "auto",
+			body:  `{"deviceId": "E2F6032048AB", "deviceType": "Humidifier", "power": "on", "auto": true, "nebulizationEfficiency": 0}`,
+			want:  switchbot.AutoMode,
+		},
+		{
+			label: "numeric level",
+			body:  `{"deviceId": "E2F6032048AB", "deviceType": "Humidifier", "power": "on", "auto": false, "nebulizationEfficiency": 38}`,
+			want:  switchbot.HumidifierMode(38),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var status switchbot.DeviceStatus
+			if err := json.Unmarshal([]byte(tt.body), &status); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := status.HumidifierMode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected humidifier mode: %d != %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("not a humidifier", func(t *testing.T) {
+		status := switchbot.DeviceStatus{Type: switchbot.Meter}
+		if _, err := status.HumidifierMode(); err == nil {
+			t.Error("error is expected for non-humidifier devices")
+		}
+	})
+}
+
+func TestDeviceStatusAirPurifierMode(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+		want  switchbot.AirPurifierMode
+	}{
+		{
+			label: "auto",
+			body:  `{"deviceId": "AIRPURIFIER1", "deviceType": "Air Purifier VOC", "power": "ON", "mode": 2, "version": "V1.0"}`,
+			want:  switchbot.AirPurifierAutoMode,
+		},
+		{
+			label: "pet",
+			body:  `{"deviceId": "AIRPURIFIER2", "deviceType": "Air Purifier Table PM2.5", "power": "ON", "mode": 4, "version": "V1.0"}`,
+			want:  switchbot.AirPurifierPetMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var status switchbot.DeviceStatus
+			if err := json.Unmarshal([]byte(tt.body), &status); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := status.AirPurifierMode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected air purifier mode: %d != %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("not an air purifier", func(t *testing.T) {
+		status := switchbot.DeviceStatus{Type: switchbot.Humidifier}
+		if _, err := status.AirPurifierMode(); err == nil {
+			t.Error("error is expected for non-air purifier devices")
+		}
+	})
+}
+
+func TestDeviceStatusMeterProCO2(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {
+        "deviceId": "B0E9FE5A1C2D",
+        "deviceType": "MeterPro(CO2)",
+        "hubDeviceId": "FA7310762361",
+        "temperature": 25.1,
+        "humidity": 48,
+        "CO2": 812,
+        "battery": 100,
+        "version": "V1.0"
+    },
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	got, err := c.Device().Status(context.Background(), "B0E9FE5A1C2D")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := switchbot.DeviceStatus{
+		ID:              "B0E9FE5A1C2D",
+		Type:            switchbot.MeterProCO2,
+		Hub:             "FA7310762361",
+		Temperature:     25.1,
+		Humidity:        48,
+		CO2:             812,
+		Battery:         100,
+		Version:         "V1.0",
+		BatteryReported: true,
+	}
+
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{}, switchbot.Mode{})); diff != "" {
+		t.Fatalf("status mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func isSameStringErr(err1, err2 error) bool {
+	if err1 == nil && err2 == nil {
+		return true
+	}
+
+	if (err1 == nil && err2 != nil) || (err1 != nil && err2 == nil) {
+		return false
+	}
+
+	return err1.Error() == err2.Error()
+}
+
+func TestDeviceStatusBrightness(t *testing.T) {
+	type wants struct {
+		IntValue int
+		IntErr   error
+
+		AmbientValue switchbot.AmbientBrightness
+		AmbientErr   error
+	}
+	tests := []struct {
+		label string
+		body  string
+		want  wants
+	}{
+		{
+			label: "color bulb",
+			body:  `{ "deviceType": "Color Bulb", "brightness": 100 }`,
+			want: wants{
+				IntValue:   100,
+				AmbientErr: errors.New("ambient brightness value is only available for motion sensor, contact sensor devices"),
+			},
+		},
+		{
+			label: "motion sensor",
+			body:  `{ "deviceType": "Motion Sensor", "brightness": "bright" }`,
+			want: wants{
+				IntValue: -1,
+				IntErr:   errors.New("integer brightness value is only available for color bulb devices"),
+
+				AmbientValue: switchbot.AmbientBrightnessBright,
+			},
+		},
+		{
+			label: "contact sensor",
+			body:  `{ "devcieType": "Contact Sensor", "brightness": "dim" }`,
+			want: wants{
+				IntValue: -1,
+				IntErr:   errors.New("integer brightness value is only available for color bulb devices"),
+
+				AmbientValue: switchbot.AmbientBrightnessDim,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(fmt.Sprintf(`{
+    "statusCode": 100,
+    "body": %s,
+    "message": "success"
+}`, tt.body)))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+			got, err := c.Device().Status(context.Background(), "E2F6032048AB")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if gotint, err := got.Brightness.Int(); gotint != tt.want.IntValue || !isSameStringErr(err, tt.want.IntErr) {
+				t.Errorf("unexpected result for int brightness\n  int value: %d != %d\n  error: %v != %v", gotint, tt.want.IntValue, err, tt.want.IntErr)
+				return
+			}
+
+			if gotAmbient, err := got.Brightness.AmbientBrightness(); gotAmbient != tt.want.AmbientValue || !isSameStringErr(err, tt.want.AmbientErr) {
+				t.Errorf("unexpected result for ambient brightness\n  ambient brightness value: %s != %s\n  error: %v != %v", gotAmbient, tt.want.AmbientValue, err, tt.want.AmbientErr)
+				return
+			}
+		})
+	}
+}
+
+func TestDeviceStatusMode(t *testing.T) {
+	tests := []struct {
+		label      string
+		body       string
+		wantInt    int
+		wantIntErr bool
+		wantString string
+	}{
+		{
+			label:      "smart fan",
+			body:       `{ "deviceId": "E2F6032048AB", "deviceType": "Smart Fan", "power": "on", "mode": 2, "speed": 3, "shaking": true, "shakeCenter": 60, "shakeRange": 60 }`,
+			wantInt:    2,
+			wantString: "2",
+		},
+		{
+			label:      "string mode",
+			body:       `{ "deviceId": "E2F6032048AB", "deviceType": "Humidifier", "mode": "auto" }`,
+			wantInt:    -1,
+			wantIntErr: true,
+			wantString: "auto",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var status switchbot.DeviceStatus
+			if err := json.Unmarshal([]byte(tt.body), &status); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := status.Mode.Int()
+			if got != tt.wantInt || (err != nil) != tt.wantIntErr {
+				t.Errorf("unexpected result for int mode\n  int value: %d != %d\n  error: %v", got, tt.wantInt, err)
+			}
+
+			if got := status.Mode.String(); got != tt.wantString {
+				t.Errorf("unexpected string mode: %s != %s", got, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestDeviceSendAndVerify(t *testing.T) {
+	newServer := func(onAfter int) (*httptest.Server, *int) {
+		var polls int
+		var mu sync.Mutex
+
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/commands") {
+					w.Write([]byte(`{"statusCode": 100, "body": {}, "message": "success"}`))
+					return
+				}
+
+				mu.Lock()
+				polls++
+				power := "off"
+				if onAfter > 0 && polls > onAfter {
+					power = "on"
+				}
+				mu.Unlock()
+
+				w.Write([]byte(fmt.Sprintf(`{"statusCode": 100, "body": {"deviceId": "CA3A5E4CB1D0", "deviceType": "Bot", "power": %q}, "message": "success"}`, power)))
+			}),
+		)
+
+		return srv, &polls
+	}
+
+	isOn := func(status switchbot.DeviceStatus) bool {
+		return status.Power.ToLower() == "on"
+	}
+
+	t.Run("verified after two polls", func(t *testing.T) {
+		srv, polls := newServer(2)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		if err := c.Device().SendAndVerify(context.Background(), "CA3A5E4CB1D0", switchbot.TurnOnCommand(), isOn, 5*time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		if *polls != 3 {
+			t.Errorf("unexpected number of status polls: %d != 3", *polls)
+		}
+	})
+
+	t.Run("not verified", func(t *testing.T) {
+		srv, _ := newServer(0)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		err := c.Device().SendAndVerify(context.Background(), "CA3A5E4CB1D0", switchbot.TurnOnCommand(), isOn, 250*time.Millisecond)
+		if !errors.Is(err, switchbot.ErrNotVerified) {
+			t.Fatalf("ErrNotVerified is expected but got %v", err)
+		}
+	})
+}
+
+func TestDeviceToggleAndConfirm(t *testing.T) {
+	tests := []struct {
+		label   string
+		initial string
+		want    switchbot.PowerState
+	}{
+		{label: "off to on", initial: "off", want: switchbot.PowerOn},
+		{label: "on to off", initial: "on", want: switchbot.PowerOff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				power   = tt.initial
+				toggled bool
+				polls   int
+			)
+
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if strings.HasSuffix(r.URL.Path, "/commands") {
+						var req switchbot.DeviceCommandRequest
+						if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+							t.Error(err)
+						}
+						if req.Command != "toggle" {
+							t.Errorf("unexpected command: %s", req.Command)
+						}
+						toggled = true
+
+						w.Write([]byte(`{"statusCode": 100, "body": {}, "message": "success"}`))
+						return
+					}
+
+					// the flipped state is reported from the second poll after toggling
+					if toggled {
+						polls++
+						if polls == 2 {
+							if power == "on" {
+								power = "off"
+							} else {
+								power = "on"
+							}
+						}
+					}
+
+					w.Write([]byte(fmt.Sprintf(`{"statusCode": 100, "body": {"deviceId": "6055F930FF22", "deviceType": "Plug Mini (JP)", "power": %q}, "message": "success"}`, power)))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+			got, err := c.Device().ToggleAndConfirm(context.Background(), "6055F930FF22", 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected power state: %s != %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceUnlockTemporarily(t *testing.T) {
+	type call struct {
+		command string
+		at      time.Time
+	}
+
+	newServer := func() (*httptest.Server, func() []call) {
+		var (
+			mu    sync.Mutex
+			calls []call
+		)
+
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req switchbot.DeviceCommandRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+
+				mu.Lock()
+				calls = append(calls, call{command: req.Command, at: time.Now()})
+				mu.Unlock()
+
+				w.Write([]byte(`{"statusCode": 100, "body": {}, "message": "success"}`))
+			}),
+		)
+
+		return srv, func() []call {
+			mu.Lock()
+			defer mu.Unlock()
+			return calls
+		}
+	}
+
+	t.Run("relock after delay", func(t *testing.T) {
+		srv, calls := newServer()
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		const d = 100 * time.Millisecond
+		if err := c.Device().UnlockTemporarily(context.Background(), "F7538E1ABCEB", d); err != nil {
+			t.Fatal(err)
+		}
+
+		got := calls()
+		if len(got) != 2 || got[0].command != "unlock" || got[1].command != "lock" {
+			t.Fatalf("unlock then lock are expected but got %+v", got)
+		}
+		if delay := got[1].at.Sub(got[0].at); delay < d {
+			t.Errorf("lock is sent too early: %s < %s", delay, d)
+		}
+	})
+
+	t.Run("relock on cancel", func(t *testing.T) {
+		srv, calls := newServer()
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := c.Device().UnlockTemporarily(ctx, "F7538E1ABCEB", time.Hour)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("context.DeadlineExceeded is expected but got %v", err)
+		}
+
+		got := calls()
+		if len(got) != 2 || got[1].command != "lock" {
+			t.Fatalf("the device must be locked on cancel but got %+v", got)
+		}
+	})
+}
+
+func TestDeviceCreateKey(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if want := "/v1.1/devices/F7538E1ABCEB/commands"; r.URL.Path != want {
+				t.Fatalf("unexpected request path: %s != %s", r.URL.Path, want)
+			}
+
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantBody := `{"command":"createKey","parameter":"{\"name\":\"Guest Code\",\"type\":\"permanent\",\"password\":\"12345678\",\"startTime\":-62135596800,\"endTime\":-62135596800}","commandType":"command"}
+`
+			if got := string(b); got != wantBody {
+				t.Fatalf("unexpected request body:\n  got:  %s\n  want: %s", got, wantBody)
+			}
+
+			// the documented response of the command, the created key is reported through webhook
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {},
+    "message": "success"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+	got, err := c.Device().CreateKey(context.Background(), "F7538E1ABCEB", switchbot.CreateKeyParams{
+		Name:     "Guest Code",
+		Type:     switchbot.PermanentPasscode,
+		Password: "12345678",
+	})
+	if !errors.Is(err, switchbot.ErrKeyNotReturned) {
+		t.Fatalf("ErrKeyNotReturned is expected but got %v", err)
+	}
+	if got != nil {
+		t.Errorf("no key should be returned but %+v", got)
+	}
+
+	t.Run("invalid password", func(t *testing.T) {
+		if _, err := c.Device().CreateKey(context.Background(), "F7538E1ABCEB", switchbot.CreateKeyParams{
+			Name:     "Guest Code",
+			Type:     switchbot.PermanentPasscode,
+			Password: "1234",
+		}); err == nil {
+			t.Error("error is expected for too short password")
+		}
+	})
+}
+
+func TestDeviceDeleteKey(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/F7538E1ABCEB/commands",
+			`{"command":"deleteKey","parameter":"{\"id\":11}","commandType":"command"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		if err := c.Device().DeleteKey(context.Background(), "F7538E1ABCEB", 11); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+    "statusCode": 190,
+    "body": {},
+    "message": "key does not exist"
+}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		err := c.Device().DeleteKey(context.Background(), "F7538E1ABCEB", 99)
+		if !errors.Is(err, switchbot.ErrKeyNotFound) {
+			t.Fatalf("ErrKeyNotFound is expected but got %v", err)
+		}
+	})
+
+	t.Run("device offline", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+    "statusCode": 161,
+    "body": {},
+    "message": "device is offline"
+}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		err := c.Device().DeleteKey(context.Background(), "F7538E1ABCEB", 11)
+		if err == nil || errors.Is(err, switchbot.ErrKeyNotFound) {
+			t.Fatalf("device offline error is expected but got %v", err)
+		}
+	})
+
+	t.Run("other internal error", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+    "statusCode": 190,
+    "body": {},
+    "message": "invalid command format"
+}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		err := c.Device().DeleteKey(context.Background(), "F7538E1ABCEB", 99)
+		if err == nil {
+			t.Fatal("error is expected")
+		}
+		if errors.Is(err, switchbot.ErrKeyNotFound) {
+			t.Fatalf("ErrKeyNotFound must not be returned for other errors: %v", err)
+		}
+	})
+}
+
+func TestInfraredCommands(t *testing.T) {
+	tests := []struct {
+		label string
+		got   switchbot.Command
+		want  switchbot.Command
+	}{
+		{label: "turn on", got: switchbot.TVCommands{}.TurnOn(), want: switchbot.TurnOnCommand()},
+		{label: "turn off", got: switchbot.LightCommands{}.TurnOff(), want: switchbot.TurnOffCommand()},
+		{label: "ac set all", got: switchbot.AirConditionerCommands{}.SetAll(26, switchbot.ACCool, switchbot.ACAutoSpeed, switchbot.PowerOn), want: switchbot.ACSetAllCommand(26, switchbot.ACCool, switchbot.ACAutoSpeed, switchbot.PowerOn)},
+		{label: "tv set channel", got: switchbot.TVCommands{}.SetChannel(15), want: switchbot.SetChannelCommand(15)},
+		{label: "tv volume add", got: switchbot.TVCommands{}.VolumeAdd(), want: switchbot.VolumeAddCommand()},
+		{label: "tv volume sub", got: switchbot.TVCommands{}.VolumeSub(), want: switchbot.VolumeSubCommand()},
+		{label: "tv channel add", got: switchbot.TVCommands{}.ChannelAdd(), want: switchbot.ChannelAddCommand()},
+		{label: "tv channel sub", got: switchbot.TVCommands{}.ChannelSub(), want: switchbot.ChannelSubCommand()},
+		{label: "dvd mute", got: switchbot.DVDCommands{}.SetMute(), want: switchbot.SetMuteCommand()},
+		{label: "dvd fast forward", got: switchbot.DVDCommands{}.FastForward(), want: switchbot.FastForwardCommand()},
+		{label: "dvd rewind", got: switchbot.DVDCommands{}.Rewind(), want: switchbot.RewindCommand()},
+		{label: "dvd next", got: switchbot.DVDCommands{}.Next(), want: switchbot.NextCommand()},
+		{label: "dvd previous", got: switchbot.DVDCommands{}.Previous(), want: switchbot.PreviousCommand()},
+		{label: "dvd pause", got: switchbot.DVDCommands{}.Pause(), want: switchbot.PauseCommand()},
+		{label: "dvd play", got: switchbot.DVDCommands{}.Play(), want: switchbot.PlayCommand()},
+		{label: "dvd stop", got: switchbot.DVDCommands{}.Stop(), want: switchbot.StopPlayerCommand()},
+		{label: "speaker play", got: switchbot.SpeakerCommands{}.Play(), want: switchbot.PlayCommand()},
+		{label: "speaker volume add", got: switchbot.SpeakerCommands{}.VolumeAdd(), want: switchbot.VolumeAddCommand()},
+		{label: "fan swing", got: switchbot.FanCommands{}.Swing(), want: switchbot.FanSwingCommand()},
+		{label: "fan timer", got: switchbot.FanCommands{}.Timer(), want: switchbot.FanTimerCommand()},
+		{label: "fan low speed", got: switchbot.FanCommands{}.LowSpeed(), want: switchbot.FanLowSpeedCommand()},
+		{label: "fan middle speed", got: switchbot.FanCommands{}.MiddleSpeed(), want: switchbot.FanMiddleSpeedCommand()},
+		{label: "fan high speed", got: switchbot.FanCommands{}.HighSpeed(), want: switchbot.FanHighSpeedCommand()},
+		{label: "light brightness up", got: switchbot.LightCommands{}.BrightnessUp(), want: switchbot.LightBrightnessUpCommand()},
+		{label: "light brightness down", got: switchbot.LightCommands{}.BrightnessDown(), want: switchbot.LightBrightnessDownCommand()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want.Request(), tt.got.Request()); diff != "" {
+				t.Errorf("command mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCustomCommand(t *testing.T) {
+	t.Run("command type", func(t *testing.T) {
+		custom, err := switchbot.CustomCommand("My Button")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			label string
+			cmd   switchbot.Command
+			want  string
+		}{
+			{label: "custom", cmd: custom, want: "customize"},
+			{label: "button push", cmd: switchbot.ButtonPushCommand("My Button"), want: "customize"},
+			{label: "standard", cmd: switchbot.TurnOnCommand(), want: "command"},
+			{label: "standard tv", cmd: switchbot.TVCommands{}.SetChannel(15), want: "command"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				if got := tt.cmd.Request().CommandType; got != tt.want {
+					t.Errorf("unexpected command type: %s != %s", got, tt.want)
+				}
+			})
+		}
+
+		if got := custom.Request().Command; got != "My Button" {
+			t.Errorf("unexpected command: %s", got)
+		}
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		if _, err := switchbot.CustomCommand(""); err == nil {
+			t.Error("error is expected for empty button name")
+		}
+	})
+}
+
+func TestCommandFromJSON(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		setAll := switchbot.ACSetAllCommand(26, switchbot.ACCool, switchbot.ACAutoSpeed, switchbot.PowerOn)
+		fanGear, err := switchbot.AirPurifierSetFanGearCommand(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			label string
+			cmd   switchbot.Command
+		}{
+			{label: "turn on", cmd: switchbot.TurnOnCommand()},
+			{label: "set channel", cmd: switchbot.SetChannelCommand(15)},
+			{label: "ac set all", cmd: setAll},
+			{label: "air purifier fan gear", cmd: fanGear},
+			{label: "button push", cmd: switchbot.ButtonPushCommand("My Button")},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				b, err := json.Marshal(tt.cmd.Request())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := switchbot.CommandFromJSON(b)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if diff := cmp.Diff(tt.cmd.Request(), got.Request()); diff != "" {
+					t.Errorf("command mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := []struct {
+			label string
+			json  string
+		}{
+			{label: "broken json", json: `{"command":`},
+			{label: "unknown field", json: `{"command":"turnOn","unknown":1}`},
+			{label: "empty command", json: `{"parameter":"default","commandType":"command"}`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				if _, err := switchbot.CommandFromJSON([]byte(tt.json)); err == nil {
+					t.Error("error is expected")
+				}
+			})
+		}
+	})
+}
+
+func testDeviceCommand(t *testing.T, wantPath string, wantBody string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != wantPath {
+			t.Fatalf("unexpected request path: %s != %s", r.URL.Path, wantPath)
+		}
+
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(b); got != wantBody {
+			t.Fatalf("unexpected request body:\n  got:  %s\n  want: %s",
+				got, wantBody,
+			)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+    "statusCode": 100,
+    "body": {},
+    "message": "success"
+}`))
+	})
+}
+
+func TestDeviceStatuses(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxIn    int
+	)
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxIn {
+				maxIn = inFlight
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.1/devices/"), "/status")
+			if id == "BROKEN" {
+				w.Write([]byte(`{"statusCode": 190, "body": {}, "message": ""}`))
+				return
+			}
+			w.Write([]byte(fmt.Sprintf(`{"statusCode": 100, "body": {"deviceId": %q, "deviceType": "Meter", "temperature": 25.5}, "message": "success"}`, id)))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	ids := []string{"A", "B", "C", "D", "BROKEN"}
+	statuses, errs := c.Device().Statuses(context.Background(), ids, 2)
+
+	if maxIn > 2 {
+		t.Errorf("%d requests were in flight at the same time, want at most 2", maxIn)
+	}
+
+	if len(statuses) != 4 {
+		t.Errorf("unexpected number of statuses: %d != 4", len(statuses))
+	}
+	for _, id := range []string{"A", "B", "C", "D"} {
+		status, ok := statuses[id]
+		if !ok {
+			t.Errorf("status for %s is not found", id)
+			continue
+		}
+		if status.ID != id || status.Temperature != 25.5 {
+			t.Errorf("unexpected status for %s: %+v", id, status)
+		}
+	}
+
+	if len(errs) != 1 {
+		t.Errorf("unexpected number of errors: %d != 1", len(errs))
+	}
+	if errs["BROKEN"] == nil {
+		t.Error("error for BROKEN is expected")
+	}
+}
+
+func TestDeviceStatusesCanceled(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("no request is expected but got %s", r.URL.Path)
+		}),
+	)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	statuses, errs := c.Device().Statuses(ctx, []string{"A", "B"}, 1)
+
+	if len(statuses) != 0 {
+		t.Errorf("no status is expected but got %d", len(statuses))
+	}
+	for _, id := range []string{"A", "B"} {
+		if !errors.Is(errs[id], context.Canceled) {
+			t.Errorf("context.Canceled is expected for %s but got %v", id, errs[id])
+		}
+	}
+}
+
+func TestDeviceStatusToGenericAccessory(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	intPtr := func(i int) *int { return &i }
+	floatPtr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		label string
+		body  string
+		want  switchbot.GenericAccessory
+	}{
+		{
+			label: "color bulb",
+			body:  `{"deviceId": "6055F92FCFD2", "deviceType": "Color Bulb", "power": "on", "brightness": 80, "color": "255:0:0"}`,
+			want: switchbot.GenericAccessory{
+				ID:         "6055F92FCFD2",
+				Type:       switchbot.ColorBulb,
+				On:         boolPtr(true),
+				Brightness: intPtr(80),
+			},
+		},
+		{
+			label: "meter in fahrenheit",
+			body:  `{"deviceId": "C271111EC0AB", "deviceType": "Meter", "temperature": 77, "humidity": 52, "scale": "FAHRENHEIT", "battery": 100}`,
+			want: switchbot.GenericAccessory{
+				ID:          "C271111EC0AB",
+				Type:        switchbot.Meter,
+				Temperature: floatPtr(25),
+				Humidity:    intPtr(52),
+				Battery:     intPtr(100),
+			},
+		},
+		{
+			label: "meter without battery",
+			body:  `{"deviceId": "C271111EC0AB", "deviceType": "Meter", "temperature": 25, "humidity": 52, "scale": "CELSIUS"}`,
+			want: switchbot.GenericAccessory{
+				ID:          "C271111EC0AB",
+				Type:        switchbot.Meter,
+				Temperature: floatPtr(25),
+				Humidity:    intPtr(52),
+			},
+		},
+		{
+			label: "curtain",
+			body:  `{"deviceId": "E2F6032048AB", "deviceType": "Curtain", "slidePosition": 30, "battery": 80}`,
+			want: switchbot.GenericAccessory{
+				ID:       "E2F6032048AB",
+				Type:     switchbot.Curtain,
+				Position: intPtr(30),
+				Battery:  intPtr(80),
+			},
+		},
+		{
+			label: "lock",
+			body:  `{"deviceId": "F7538E1ABCEB", "deviceType": "Smart Lock", "lockState": "locked", "battery": 90}`,
+			want: switchbot.GenericAccessory{
+				ID:      "F7538E1ABCEB",
+				Type:    switchbot.Lock,
+				Locked:  boolPtr(true),
+				Battery: intPtr(90),
+			},
+		},
+		{
+			label: "contact sensor",
+			body:  `{"deviceId": "F1F2F3F4F5F6", "deviceType": "Contact Sensor", "moveDetected": false, "openState": "timeOutNotClose", "battery": 60}`,
+			want: switchbot.GenericAccessory{
+				ID:             "F1F2F3F4F5F6",
+				Type:           switchbot.ContactSensor,
+				MotionDetected: boolPtr(false),
+				ContactOpen:    boolPtr(true),
+				Battery:        intPtr(60),
+			},
+		},
+		{
+			label: "contact sensor without open state",
+			body:  `{"deviceId": "F1F2F3F4F5F6", "deviceType": "Contact Sensor", "moveDetected": true}`,
+			want: switchbot.GenericAccessory{
+				ID:             "F1F2F3F4F5F6",
+				Type:           switchbot.ContactSensor,
+				MotionDetected: boolPtr(true),
+			},
+		},
+		{
+			label: "relay switch",
+			body:  `{"deviceId": "AABBCCDDEEFF", "deviceType": "Relay Switch 1PM", "switchStatus": 0, "power": 0}`,
+			want: switchbot.GenericAccessory{
+				ID:   "AABBCCDDEEFF",
+				Type: switchbot.RelaySwitch1PM,
+				On:   boolPtr(false),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var status switchbot.DeviceStatus
+			if err := json.Unmarshal([]byte(tt.body), &status); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, status.ToGenericAccessory()); diff != "" {
+				t.Fatalf("accessory mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeviceCommand(t *testing.T) {
+	t.Run("create a temporary passcode", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/F7538E1ABCEB/commands",
+			`{"command":"createKey","parameter":"{\"name\":\"Guest Code\",\"type\":\"timeLimit\",\"password\":\"12345678\",\"startTime\":1664640056,\"endTime\":1665331432}","commandType":"command"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		cmd, err := switchbot.CreateKeyCommand("Guest Code", switchbot.TimeLimitPasscode, "12345678", time.Date(2022, time.October, 1, 16, 00, 56, 0, time.UTC), time.Date(2022, time.October, 9, 16, 3, 52, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Device().Command(context.Background(), "F7538E1ABCEB", cmd); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("turn a bot on", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/210/commands",
+			`{"command":"turnOn","parameter":"default","commandType":"command"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		if err := c.Device().Command(context.Background(), "210", switchbot.TurnOnCommand()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("set the color value of a Color Bulb Request", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/84F70353A411/commands",
+			`{"command":"setColor","parameter":"122:80:20","commandType":"command"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		cmd, err := switchbot.SetColorCommand(122, 80, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Device().Command(context.Background(), "84F70353A411", cmd); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("set an invalid color value", func(t *testing.T) {
+		tests := []struct {
+			label   string
+			r, g, b int
+		}{
+			{label: "red over 255", r: 300, g: 0, b: 0},
+			{label: "green under 0", r: 0, g: -5, b: 0},
+			{label: "blue over 255", r: 0, g: 0, b: 256},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				if _, err := switchbot.SetColorCommand(tt.r, tt.g, tt.b); err == nil {
+					t.Error("error is expected")
+				}
+			})
+		}
+	})
+
+	t.Run("set an air conditioner", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/02-202007201626-70/commands",
+			`{"command":"setAll","parameter":"26,1,3,on","commandType":"command"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		if err := c.Device().Command(context.Background(), "02-202007201626-70", switchbot.ACSetAllCommand(26, switchbot.ACAuto, switchbot.ACMedium, switchbot.PowerOn)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("set trigger a customized button", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/02-202007201626-10/commands",
+			`{"command":"ボタン","parameter":"default","commandType":"customize"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		if err := c.Device().Command(context.Background(), "02-202007201626-10", switchbot.ButtonPushCommand("ボタン")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("robot vacuum cleaner K10+", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			cmd      switchbot.Command
+			wantBody string
+		}{
+			{
+				label:    "start",
+				cmd:      switchbot.StartCommand(),
+				wantBody: `{"command":"start","parameter":"default","commandType":"command"}`,
+			},
+			{
+				label:    "stop",
+				cmd:      switchbot.StopCommand(),
+				wantBody: `{"command":"stop","parameter":"default","commandType":"command"}`,
+			},
+			{
+				label:    "dock",
+				cmd:      switchbot.DockCommand(),
+				wantBody: `{"command":"dock","parameter":"default","commandType":"command"}`,
+			},
+			{
+				label:    "set power level",
+				cmd:      switchbot.PowLevelCommand(switchbot.StrongVacuumPowerLevel),
+				wantBody: `{"command":"PowLevel","parameter":"2","commandType":"command"}`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/K10PLUS00001/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
+
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				if err := c.Device().Command(context.Background(), "K10PLUS00001", tt.cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+
+	t.Run("set the mode of an evaporative humidifier", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/D83BDA1C2E0F/commands",
+			`{"command":"setMode","parameter":"{\"mode\":7,\"targetHumidity\":50}","commandType":"command"}
+`,
+		))
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		cmd, err := switchbot.SetEvaporativeHumidifierModeCommand(switchbot.EvaporativeHumidifierAutoMode, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Device().Command(context.Background(), "D83BDA1C2E0F", cmd); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, humidity := range []int{-1, 101} {
+			if _, err := switchbot.SetEvaporativeHumidifierModeCommand(switchbot.EvaporativeHumidifierAutoMode, humidity); err == nil {
+				t.Errorf("error is expected for target humidity %d", humidity)
+			}
+		}
+	})
+
+	t.Run("robot vacuum cleaner S10", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			cmd      switchbot.Command
+			wantBody string
+		}{
+			{
+				label:    "set volume",
+				cmd:      switchbot.SetVolumeCommand(50),
+				wantBody: `{"command":"setVolume","parameter":"50","commandType":"command"}`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/S10000000001/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
+
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				if err := c.Device().Command(context.Background(), "S10000000001", tt.cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+
+	t.Run("battery circulator fan", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			cmd      switchbot.Command
+			wantBody string
+		}{
+			{
+				label:    "set night light mode",
+				cmd:      switchbot.SetNightLightModeCommand(switchbot.NightLightMode1),
+				wantBody: `{"command":"setNightLightMode","parameter":"1","commandType":"command"}`,
+			},
+			{
+				label:    "turn night light off",
+				cmd:      switchbot.SetNightLightModeCommand(switchbot.NightLightOff),
+				wantBody: `{"command":"setNightLightMode","parameter":"off","commandType":"command"}`,
+			},
+			{
+				label:    "set wind mode",
+				cmd:      switchbot.SetWindModeCommand(switchbot.NaturalWindMode),
+				wantBody: `{"command":"setWindMode","parameter":"natural","commandType":"command"}`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/CIRCULATOR01/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
+
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				if err := c.Device().Command(context.Background(), "CIRCULATOR01", tt.cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+
+	t.Run("set the mode of a relay switch", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/7C2C67F3A6B4/commands",
+			`{"command":"setMode","parameter":"2","commandType":"command"}
+`,
+		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
-		got, err := c.Device().Status(context.Background(), "C271111EC0AB")
-		if err != nil {
+
+		if err := c.Device().Command(context.Background(), "7C2C67F3A6B4", switchbot.SetRelaySwitchModeCommand(switchbot.RelayDetachedMode)); err != nil {
 			t.Fatal(err)
 		}
+	})
 
-		want := switchbot.DeviceStatus{
-			ID:          "C271111EC0AB",
-			Type:        switchbot.Meter,
-			Hub:         "FA7310762361",
-			Humidity:    52,
-			Temperature: 26.1,
+	t.Run("set the position of a roller shade", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			position int
+			wantBody string
+		}{
+			{
+				label:    "in range",
+				position: 50,
+				wantBody: `{"command":"setPosition","parameter":"50","commandType":"command"}`,
+			},
+			{
+				label:    "less than 0",
+				position: -10,
+				wantBody: `{"command":"setPosition","parameter":"0","commandType":"command"}`,
+			},
+			{
+				label:    "over 100",
+				position: 120,
+				wantBody: `{"command":"setPosition","parameter":"100","commandType":"command"}`,
+			},
 		}
 
-		if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{})); diff != "" {
-			t.Fatalf("status mismatch (-want +got):\n%s", diff)
-		}
-	})
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/ROLLERSHADE1/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
 
-	// https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#switchbot-curtain-example
-	t.Run("curtain", func(t *testing.T) {
-		srv := httptest.NewServer(
-			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.URL.Path != "/v1.1/devices/E2F6032048AB/status" {
-					t.Fatalf("unexpected request path: %s", r.URL.Path)
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				if err := c.Device().Command(context.Background(), "ROLLERSHADE1", switchbot.RollerShadeSetPositionCommand(tt.position)); err != nil {
+					t.Fatal(err)
 				}
+			})
+		}
+	})
 
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte(`{
-    "statusCode": 100,
-    "body": {
-        "deviceId": "E2F6032048AB",
-        "deviceType": "Curtain",
-        "hubDeviceId": "FA7310762361",
-        "calibrate": true,
-        "group": false,
-        "moving": false,
-        "slidePosition": 0
-    },
-    "message": "success"
-}`))
-			}),
-		)
+	t.Run("set the fan gear of an air purifier", func(t *testing.T) {
+		srv := httptest.NewServer(testDeviceCommand(
+			t,
+			"/v1.1/devices/AIRPURIFIER1/commands",
+			`{"command":"setMode","parameter":"{\"mode\":1,\"fanGear\":2}","commandType":"command"}
+`,
+		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
-		got, err := c.Device().Status(context.Background(), "E2F6032048AB")
+
+		cmd, err := switchbot.AirPurifierSetFanGearCommand(2)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		want := switchbot.DeviceStatus{
-			ID:            "E2F6032048AB",
-			Type:          switchbot.Curtain,
-			Hub:           "FA7310762361",
-			IsCalibrated:  true,
-			IsGrouped:     false,
-			IsMoving:      false,
-			SlidePosition: 0,
+		if err := c.Device().Command(context.Background(), "AIRPURIFIER1", cmd); err != nil {
+			t.Fatal(err)
 		}
 
-		if diff := cmp.Diff(want, got, cmp.AllowUnexported(switchbot.BrightnessState{})); diff != "" {
-			t.Fatalf("status mismatch (-want +got):\n%s", diff)
+		for _, gear := range []int{0, 4} {
+			if _, err := switchbot.AirPurifierSetFanGearCommand(gear); err == nil {
+				t.Errorf("error is expected for fan gear %d", gear)
+			}
 		}
 	})
-}
 
-func isSameStringErr(err1, err2 error) bool {
-	if err1 == nil && err2 == nil {
-		return true
-	}
+	t.Run("set the color temperature preset of a ceiling light", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			preset   switchbot.ColorPreset
+			wantBody string
+		}{
+			{
+				label:    "warm",
+				preset:   switchbot.WarmColorPreset,
+				wantBody: `{"command":"setColorTemperature","parameter":"2700","commandType":"command"}`,
+			},
+			{
+				label:    "neutral",
+				preset:   switchbot.NeutralColorPreset,
+				wantBody: `{"command":"setColorTemperature","parameter":"4000","commandType":"command"}`,
+			},
+			{
+				label:    "cool",
+				preset:   switchbot.CoolColorPreset,
+				wantBody: `{"command":"setColorTemperature","parameter":"5000","commandType":"command"}`,
+			},
+			{
+				label:    "daylight",
+				preset:   switchbot.DaylightColorPreset,
+				wantBody: `{"command":"setColorTemperature","parameter":"6500","commandType":"command"}`,
+			},
+		}
 
-	if (err1 == nil && err2 != nil) || (err1 != nil && err2 == nil) {
-		return false
-	}
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/CEILING1/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
 
-	return err1.Error() == err2.Error()
-}
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-func TestDeviceStatusBrightness(t *testing.T) {
-	type wants struct {
-		IntValue int
-		IntErr   error
+				if err := c.Device().Command(context.Background(), "CEILING1", switchbot.CeilingSetPresetCommand(tt.preset)); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
 
-		AmbientValue switchbot.AmbientBrightness
-		AmbientErr   error
-	}
-	tests := []struct {
-		label string
-		body  string
-		want  wants
-	}{
-		{
-			label: "color bulb",
-			body:  `{ "deviceType": "Color Bulb", "brightness": 100 }`,
-			want: wants{
-				IntValue:   100,
-				AmbientErr: errors.New("ambient brightness value is only available for motion sensor, contact sensor devices"),
+	t.Run("set the position of a curtain", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			mode     switchbot.SetPositionMode
+			wantBody string
+		}{
+			{
+				label:    "default mode",
+				mode:     switchbot.DefaultMode,
+				wantBody: `{"command":"setPosition","parameter":"0,ff,50","commandType":"command"}`,
 			},
-		},
-		{
-			label: "motion sensor",
-			body:  `{ "deviceType": "Motion Sensor", "brightness": "bright" }`,
-			want: wants{
-				IntValue: -1,
-				IntErr:   errors.New("integer brightness value is only available for color bulb devices"),
-
-				AmbientValue: switchbot.AmbientBrightnessBright,
+			{
+				label:    "performance mode",
+				mode:     switchbot.PerformanceMode,
+				wantBody: `{"command":"setPosition","parameter":"0,0,50","commandType":"command"}`,
 			},
-		},
-		{
-			label: "contact sensor",
-			body:  `{ "devcieType": "Contact Sensor", "brightness": "dim" }`,
-			want: wants{
-				IntValue: -1,
-				IntErr:   errors.New("integer brightness value is only available for color bulb devices"),
-
-				AmbientValue: switchbot.AmbientBrightnessDim,
+			{
+				label:    "silent mode",
+				mode:     switchbot.SilentMode,
+				wantBody: `{"command":"setPosition","parameter":"0,1,50","commandType":"command"}`,
 			},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.label, func(t *testing.T) {
-			srv := httptest.NewServer(
-				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-					w.Write([]byte(fmt.Sprintf(`{
-    "statusCode": 100,
-    "body": %s,
-    "message": "success"
-}`, tt.body)))
-				}),
-			)
-			defer srv.Close()
+		}
 
-			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
-			got, err := c.Device().Status(context.Background(), "E2F6032048AB")
-			if err != nil {
-				t.Fatal(err)
-			}
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/CURTAIN1/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
 
-			if gotint, err := got.Brightness.Int(); gotint != tt.want.IntValue || !isSameStringErr(err, tt.want.IntErr) {
-				t.Errorf("unexpected result for int brightness\n  int value: %d != %d\n  error: %v != %v", gotint, tt.want.IntValue, err, tt.want.IntErr)
-				return
-			}
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-			if gotAmbient, err := got.Brightness.AmbientBrightness(); gotAmbient != tt.want.AmbientValue || !isSameStringErr(err, tt.want.AmbientErr) {
-				t.Errorf("unexpected result for ambient brightness\n  ambient brightness value: %s != %s\n  error: %v != %v", gotAmbient, tt.want.AmbientValue, err, tt.want.AmbientErr)
-				return
-			}
-		})
-	}
-}
+				cmd, err := switchbot.CurtainSetPositionCommand(0, tt.mode, 50)
+				if err != nil {
+					t.Fatal(err)
+				}
 
-func testDeviceCommand(t *testing.T, wantPath string, wantBody string) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != wantPath {
-			t.Fatalf("unexpected request path: %s != %s", r.URL.Path, wantPath)
+				if err := c.Device().Command(context.Background(), "CURTAIN1", cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
 		}
 
-		b, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			t.Fatal(err)
+		invalids := []struct {
+			label    string
+			index    int
+			mode     switchbot.SetPositionMode
+			position int
+		}{
+			{label: "negative index", index: -1, mode: switchbot.DefaultMode, position: 50},
+			{label: "unknown mode", index: 0, mode: switchbot.SetPositionMode(5), position: 50},
+			{label: "position over 100", index: 0, mode: switchbot.DefaultMode, position: 101},
 		}
 
-		if got := string(b); got != wantBody {
-			t.Fatalf("unexpected request body:\n  got:  %s\n  want: %s",
-				got, wantBody,
-			)
+		for _, tt := range invalids {
+			t.Run(tt.label, func(t *testing.T) {
+				if _, err := switchbot.CurtainSetPositionCommand(tt.index, tt.mode, tt.position); err == nil {
+					t.Error("error is expected")
+				}
+			})
 		}
-
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{
-    "statusCode": 100,
-    "body": {},
-    "message": "success"
-}`))
 	})
-}
 
-func TestDeviceCommand(t *testing.T) {
-	t.Run("create a temporary passcode", func(t *testing.T) {
+	t.Run("set the voice volume of a robot vacuum cleaner", func(t *testing.T) {
 		srv := httptest.NewServer(testDeviceCommand(
 			t,
-			"/v1.1/devices/F7538E1ABCEB/commands",
-			`{"command":"createKey","parameter":"{\"name\":\"Guest Code\",\"type\":\"timeLimit\",\"password\":\"12345678\",\"startTime\":1664640056,\"endTime\":1665331432}","commandType":"command"}
+			"/v1.1/devices/VACUUM1/commands",
+			`{"command":"setVolume","parameter":"40","commandType":"command"}
 `,
 		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		cmd, err := switchbot.CreateKeyCommand("Guest Code", switchbot.TimeLimitPasscode, "12345678", time.Date(2022, time.October, 1, 16, 00, 56, 0, time.UTC), time.Date(2022, time.October, 9, 16, 3, 52, 0, time.UTC))
+		cmd, err := switchbot.SetVacuumVolumeCommand(40)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		if err := c.Device().Command(context.Background(), "F7538E1ABCEB", cmd); err != nil {
+		if err := c.Device().Command(context.Background(), "VACUUM1", cmd); err != nil {
 			t.Fatal(err)
 		}
+
+		for _, volume := range []int{-1, 101} {
+			if _, err := switchbot.SetVacuumVolumeCommand(volume); err == nil {
+				t.Errorf("error is expected for volume %d", volume)
+			}
+		}
 	})
-	t.Run("turn a bot on", func(t *testing.T) {
+
+	t.Run("start cleaning of a robot vacuum cleaner", func(t *testing.T) {
+		tests := []struct {
+			label    string
+			action   switchbot.CleanAction
+			fanLevel int
+			times    int
+			wantBody string
+		}{
+			{
+				label:    "start clean",
+				action:   switchbot.Sweep,
+				fanLevel: 2,
+				times:    1,
+				wantBody: `{"command":"startClean","parameter":"{\"action\":\"sweep\",\"param\":{\"fanLevel\":2,\"times\":1}}","commandType":"command"}`,
+			},
+			{
+				label:    "start clean with mopping",
+				action:   switchbot.SweepMop,
+				fanLevel: 4,
+				times:    2,
+				wantBody: `{"command":"startClean","parameter":"{\"action\":\"sweep_mop\",\"param\":{\"fanLevel\":4,\"times\":2}}","commandType":"command"}`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/S10000000001/commands",
+					tt.wantBody+"\n",
+				))
+				defer srv.Close()
+
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				cmd, err := switchbot.StartCleanCommand(tt.action, tt.fanLevel, tt.times)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := c.Device().Command(context.Background(), "S10000000001", cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+
+		for _, fanLevel := range []int{0, 5} {
+			if _, err := switchbot.StartCleanCommand(switchbot.Sweep, fanLevel, 1); err == nil {
+				t.Errorf("error is expected for fan level %d", fanLevel)
+			}
+		}
+	})
+
+	t.Run("start self-cleaning of a robot vacuum cleaner", func(t *testing.T) {
 		srv := httptest.NewServer(testDeviceCommand(
 			t,
-			"/v1.1/devices/210/commands",
-			`{"command":"turnOn","parameter":"default","commandType":"command"}
+			"/v1.1/devices/S10000000001/commands",
+			`{"command":"selfClean","parameter":"1","commandType":"command"}
 `,
 		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		if err := c.Device().Command(context.Background(), "210", switchbot.TurnOnCommand()); err != nil {
+		cmd, err := switchbot.SelfCleanCommand(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Device().Command(context.Background(), "S10000000001", cmd); err != nil {
 			t.Fatal(err)
 		}
+
+		for _, mode := range []int{0, 4} {
+			if _, err := switchbot.SelfCleanCommand(mode); err == nil {
+				t.Errorf("error is expected for mode %d", mode)
+			}
+		}
 	})
 
-	t.Run("set the color value of a Color Bulb Request", func(t *testing.T) {
+	t.Run("set the wind speed of a battery circulator fan", func(t *testing.T) {
 		srv := httptest.NewServer(testDeviceCommand(
 			t,
-			"/v1.1/devices/84F70353A411/commands",
-			`{"command":"setColor","parameter":"122:80:20","commandType":"command"}
+			"/v1.1/devices/CIRCULATOR01/commands",
+			`{"command":"setWindSpeed","parameter":"75","commandType":"command"}
 `,
 		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		if err := c.Device().Command(context.Background(), "84F70353A411", switchbot.SetColorCommand(122, 80, 20)); err != nil {
+		cmd, err := switchbot.SetWindSpeedCommand(75)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Device().Command(context.Background(), "CIRCULATOR01", cmd); err != nil {
 			t.Fatal(err)
 		}
+
+		for _, speed := range []int{0, 101} {
+			if _, err := switchbot.SetWindSpeedCommand(speed); err == nil {
+				t.Errorf("error is expected for speed %d", speed)
+			}
+		}
 	})
 
-	t.Run("set an air conditioner", func(t *testing.T) {
+	t.Run("set the brightness of a light", func(t *testing.T) {
+		tests := []struct {
+			label      string
+			brightness int
+			wantErr    bool
+		}{
+			{label: "minimum", brightness: 1},
+			{label: "maximum", brightness: 100},
+			{label: "zero", brightness: 0, wantErr: true},
+			{label: "over maximum", brightness: 101, wantErr: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				cmd, err := switchbot.SetBrightnessCommand(tt.brightness)
+				if tt.wantErr {
+					if err == nil {
+						t.Error("error is expected")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/84F70353A411/commands",
+					fmt.Sprintf(`{"command":"setBrightness","parameter":"%d","commandType":"command"}
+`, tt.brightness),
+				))
+				defer srv.Close()
+
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				if err := c.Device().Command(context.Background(), "84F70353A411", cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+
+	t.Run("set the color temperature of a light", func(t *testing.T) {
+		tests := []struct {
+			label       string
+			temperature int
+			wantErr     bool
+		}{
+			{label: "minimum", temperature: switchbot.MinColorTemperature},
+			{label: "maximum", temperature: switchbot.MaxColorTemperature},
+			{label: "under minimum", temperature: 2699, wantErr: true},
+			{label: "over maximum", temperature: 6501, wantErr: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				cmd, err := switchbot.SetColorTemperatureCommand(tt.temperature)
+				if tt.wantErr {
+					if err == nil {
+						t.Error("error is expected")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				srv := httptest.NewServer(testDeviceCommand(
+					t,
+					"/v1.1/devices/84F70353A411/commands",
+					fmt.Sprintf(`{"command":"setColorTemperature","parameter":"%d","commandType":"command"}
+`, tt.temperature),
+				))
+				defer srv.Close()
+
+				c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+				if err := c.Device().Command(context.Background(), "84F70353A411", cmd); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+
+	t.Run("retract the deadbolt of a lock pro", func(t *testing.T) {
 		srv := httptest.NewServer(testDeviceCommand(
 			t,
-			"/v1.1/devices/02-202007201626-70/commands",
-			`{"command":"setAll","parameter":"26,1,3,on","commandType":"command"}
+			"/v1.1/devices/F7538E1ABCEC/commands",
+			`{"command":"deadbolt","parameter":"default","commandType":"command"}
 `,
 		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		if err := c.Device().Command(context.Background(), "02-202007201626-70", switchbot.ACSetAllCommand(26, switchbot.ACAuto, switchbot.ACMedium, switchbot.PowerOn)); err != nil {
+		if err := c.Device().Command(context.Background(), "F7538E1ABCEC", switchbot.DeadboltCommand()); err != nil {
 			t.Fatal(err)
 		}
 	})
 
-	t.Run("set trigger a customized button", func(t *testing.T) {
+	t.Run("set the mode of an air purifier", func(t *testing.T) {
 		srv := httptest.NewServer(testDeviceCommand(
 			t,
-			"/v1.1/devices/02-202007201626-10/commands",
-			`{"command":"ボタン","parameter":"default","commandType":"customize"}
+			"/v1.1/devices/AIRPURIFIER1/commands",
+			`{"command":"setMode","parameter":"{\"mode\":3}","commandType":"command"}
 `,
 		))
 		defer srv.Close()
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		if err := c.Device().Command(context.Background(), "02-202007201626-10", switchbot.ButtonPushCommand("ボタン")); err != nil {
+		if err := c.Device().Command(context.Background(), "AIRPURIFIER1", switchbot.SetAirPurifierModeCommand(switchbot.AirPurifierSleepMode)); err != nil {
 			t.Fatal(err)
 		}
 	})
@@ -7,7 +7,7 @@ import (
 	"github.com/nasa9084/go-switchbot/v4"
 )
 
-func ExamplePrintPhysicalDevices() {
+func Example_printPhysicalDevices() {
 	const (
 		openToken = "blahblahblah"
 		secretKey = "blahblahblah"
@@ -3,6 +3,7 @@ package switchbot
 import (
 	"context"
 	"errors"
+	"fmt"
 )
 
 // SceneService handles API calls related to scenes.
@@ -51,6 +52,8 @@ func (svc *SceneService) List(ctx context.Context) ([]Scene, error) {
 
 	if response.StatusCode == 190 {
 		return nil, errors.New("device internal error due to device states not synchronized with server")
+	} else if response.StatusCode != 100 {
+		return nil, fmt.Errorf("unknown error %d from scene list API: %s", response.StatusCode, response.Mesasge)
 	}
 
 	return response.Body, nil
@@ -81,6 +84,8 @@ func (svc *SceneService) Execute(ctx context.Context, id string) error {
 
 	if response.StatusCode == 190 {
 		return errors.New("device internal error due to device states not synchronized with server")
+	} else if response.StatusCode != 100 {
+		return fmt.Errorf("unknown error %d from scene execute API: %s", response.StatusCode, response.Message)
 	}
 
 	return nil
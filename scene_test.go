@@ -80,6 +80,27 @@ func TestScenes(t *testing.T) {
 	}
 }
 
+func TestScenesError(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 500,
+    "body": [],
+    "message": "internal server error"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+	got, err := c.Scene().List(context.Background())
+	if err == nil {
+		t.Fatalf("error is expected for non-100 status code but got %v", got)
+	}
+}
+
 // https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#execute-a-scene
 func TestSceneExecute(t *testing.T) {
 	srv := httptest.NewServer(
@@ -109,3 +130,22 @@ func TestSceneExecute(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSceneExecuteError(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+    "statusCode": 152,
+    "body": {},
+    "message": "scene not found"
+}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+	if err := c.Scene().Execute(context.Background(), "T02-202009221414-48924101"); err == nil {
+		t.Fatal("error is expected for non-100 status code")
+	}
+}
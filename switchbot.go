@@ -14,8 +14,12 @@ import (
 	"log"
 	"net/http"
 	"net/http/httputil"
+	"reflect"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,14 +27,87 @@ import (
 
 const DefaultEndpoint = "https://api.switch-bot.com"
 
+// DefaultUserAgent is a value of User-Agent header sent to SwitchBot API
+// when no user agent is given by WithUserAgent option. This is "go-switchbot/"
+// followed by the version of this module, e.g. "go-switchbot/v4.1.0", or
+// "go-switchbot/v4" when the version is not known from the build information.
+var DefaultUserAgent = defaultUserAgent()
+
+const modulePath = "github.com/nasa9084/go-switchbot/v4"
+
+func defaultUserAgent() string {
+	const fallback = "go-switchbot/v4"
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fallback
+	}
+
+	for _, mod := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		if mod.Path != modulePath {
+			continue
+		}
+		if mod.Replace != nil {
+			mod = mod.Replace
+		}
+		// the main module is built as "(devel)"
+		if strings.HasPrefix(mod.Version, "v") {
+			return "go-switchbot/" + mod.Version
+		}
+	}
+
+	return fallback
+}
+
+// DefaultTimeout is a timeout of the http client used when no http client is given
+// by WithHTTPClient option. This limits each request including reading the response
+// body, independently of WithTimeout: WithTimeout sets a deadline to the context of
+// the request, so a shorter one takes effect, but a longer one is still cut off after
+// DefaultTimeout. Give your http client by WithHTTPClient to change this limit.
+const DefaultTimeout = 30 * time.Second
+
+// newDefaultHTTPClient returns a http client dedicated to a SwitchBot API client,
+// whose transport is tuned for making many calls to the single API host.
+func newDefaultHTTPClient() *http.Client {
+	var transport *http.Transport
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = t.Clone()
+	} else {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	transport.MaxIdleConnsPerHost = 10
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}
+}
+
 type Client struct {
 	httpClient *http.Client
 
 	openToken string
 	secretKey string
 	endpoint  string
+	userAgent string
+
+	debug           bool
+	structuredDebug bool
+	logger          *log.Logger
+
+	withoutBodyDrain   bool
+	timeout            time.Duration
+	autoRefreshDevices bool
+	logSchemaDrift     bool
+	traceIDKey         interface{}
+
+	maxRetries   int
+	retryBackoff time.Duration
+	retryBudget  *retryBudget
 
-	debug bool
+	// nonceFunc and clock generate nonce and timestamp to sign requests.
+	nonceFunc func() string
+	clock     func() time.Time
 
 	deviceService  *DeviceService
 	sceneService   *SceneService
@@ -50,6 +127,8 @@ const (
 	HubMini PhysicalDeviceType = "Hub Mini"
 	// Hub2 is SwitchBot Hub 2 Model No. W3202100
 	Hub2 PhysicalDeviceType = "Hub 2"
+	// Hub3 is SwitchBot Hub 3 Model No. W7202100
+	Hub3 PhysicalDeviceType = "Hub 3"
 	// Bot is SwitchBot Bot Model No. SwitchBot S1
 	Bot PhysicalDeviceType = "Bot"
 	// Curtain is SwitchBot Curtain Model No. W0701600
@@ -68,24 +147,38 @@ const (
 	Humidifier PhysicalDeviceType = "Humidifier"
 	// SmartFan is SwitchBot Smart Fan Model No. W0601100
 	SmartFan PhysicalDeviceType = "Smart Fan"
+	// BatteryCirculatorFan is SwitchBot Battery Circulator Fan Model No. W3800510
+	BatteryCirculatorFan PhysicalDeviceType = "Battery Circulator Fan"
 	// StripLight is SwitchBot LED Strip Light Model No. W1701100
 	StripLight PhysicalDeviceType = "Strip Light"
 	// PlugMiniUS is SwitchBot Plug Mini (US) Model No. W1901400
 	PlugMiniUS PhysicalDeviceType = "Plug Mini (US)"
 	// PlugMiniJP is SwitchBot Plug Mini (JP) Model No. W2001400
 	PlugMiniJP PhysicalDeviceType = "Plug Mini (JP)"
+	// RelaySwitch1PM is SwitchBot Relay Switch 1PM Model No. W5502310
+	RelaySwitch1PM PhysicalDeviceType = "Relay Switch 1PM"
+	// RelaySwitch1 is SwitchBot Relay Switch 1 Model No. W5502300
+	RelaySwitch1 PhysicalDeviceType = "Relay Switch 1"
 	// Lock is SwitchBot Lock Model No. W1601700
 	Lock PhysicalDeviceType = "Smart Lock"
+	// LockPro is SwitchBot Lock Pro Model No. W3500000
+	LockPro PhysicalDeviceType = "Smart Lock Pro"
 	// RobotVacuumCleanerS1 is SwitchBot Robot Vacuum Cleaner S1 Model No. W3011000; currently only available in Japan
 	RobotVacuumCleanerS1 PhysicalDeviceType = "Robot Vacuum Cleaner S1"
 	// RobotVacuumCleanerS1Plus is SwitchBot Robot Vacuum Cleaner S1 Plus Model No. W3011010; currently only available in Japan
 	RobotVacuumCleanerS1Plus PhysicalDeviceType = "Robot Vacuum Cleaner S1 Plus"
 	// WoSweeperMini is SwitchBot Robot Vacuum Cleaner K10+ Model No. W3011020
 	WoSweeperMini PhysicalDeviceType = "WoSweeperMini"
+	// RobotVacuumCleanerK10PlusPro is SwitchBot Robot Vacuum Cleaner K10+ Pro Model No. W3011026
+	RobotVacuumCleanerK10PlusPro PhysicalDeviceType = "Robot Vacuum Cleaner K10+ Pro"
+	// RobotVacuumCleanerS10 is SwitchBot Floor Cleaning Robot S10 Model No. W3211800
+	RobotVacuumCleanerS10 PhysicalDeviceType = "Robot Vacuum Cleaner S10"
 	// MotionSensor is SwitchBot Motion Sensor Model No. W1101500
 	MotionSensor PhysicalDeviceType = "Motion Sensor"
 	// ContactSensor is SwitchBot Contact Sensor Model No. W1201500
 	ContactSensor PhysicalDeviceType = "Contact Sensor"
+	// WaterDetector is SwitchBot Water Leak Detector Model No. W4402000
+	WaterDetector PhysicalDeviceType = "Water Detector"
 	// ColorBulb is SwitchBot Color Bulb Model No. W1401400
 	ColorBulb PhysicalDeviceType = "Color Bulb"
 	// MeterPlus is SwitchBot Thermometer and Hygrometer Plus (JP) Model No. W2201500 / (US) Model No. W2301500
@@ -104,14 +197,105 @@ const (
 	PanTiltCam PhysicalDeviceType = "Pan/Tilt Cam"
 	// PanTiltCam2K is SwitchBot Pan/Tilt Cam 2K Model No. W3101100
 	PanTiltCam2K PhysicalDeviceType = "Pan/Tilt Cam 2K"
+	// VideoDoorbell is SwitchBot Video Doorbell Model No. W6602310
+	VideoDoorbell PhysicalDeviceType = "Video Doorbell"
 	// BlindTilt is SwitchBot Blind Tilt Model No. W2701600
 	BlindTilt PhysicalDeviceType = "Blind Tilt"
+	// RollerShade is SwitchBot Roller Shade Model No. W5000000
+	RollerShade PhysicalDeviceType = "Roller Shade"
 	// MeterPro is SwitchBot Thermometer and Hygrometer Pro Model No. W4900000
 	MeterPro PhysicalDeviceType = "MeterPro"
 	// MeterPro(CO2) is SwitchBot CO2 Sensor Model No. W4900010
 	MeterProCO2 PhysicalDeviceType = "MeterPro(CO2)"
+	// AirPurifierVOC is SwitchBot Air Purifier VOC Model No. W5302300
+	AirPurifierVOC PhysicalDeviceType = "Air Purifier VOC"
+	// AirPurifierTableVOC is SwitchBot Air Purifier Table VOC Model No. W5302310
+	AirPurifierTableVOC PhysicalDeviceType = "Air Purifier Table VOC"
+	// AirPurifierPM25 is SwitchBot Air Purifier PM2.5 Model No. W5302100
+	AirPurifierPM25 PhysicalDeviceType = "Air Purifier PM2.5"
+	// AirPurifierTablePM25 is SwitchBot Air Purifier Table PM2.5 Model No. W5302110
+	AirPurifierTablePM25 PhysicalDeviceType = "Air Purifier Table PM2.5"
 )
 
+// IsHub returns true if the device type is one of SwitchBot hubs.
+func (typ PhysicalDeviceType) IsHub() bool {
+	switch typ {
+	case Hub, HubPlus, HubMini, Hub2, Hub3:
+		return true
+	}
+	return false
+}
+
+// IsMeter returns true if the device type is one of thermometer and hygrometer devices.
+func (typ PhysicalDeviceType) IsMeter() bool {
+	switch typ {
+	case Meter, MeterPlus, MeterPlusJP, MeterPlusUS, WoIOSensor, MeterPro, MeterProCO2:
+		return true
+	}
+	return false
+}
+
+// IsPlug returns true if the device type is one of plug devices.
+func (typ PhysicalDeviceType) IsPlug() bool {
+	switch typ {
+	case Plug, PlugMiniUS, PlugMiniJP:
+		return true
+	}
+	return false
+}
+
+// IsLight returns true if the device type is one of light devices.
+func (typ PhysicalDeviceType) IsLight() bool {
+	switch typ {
+	case StripLight, ColorBulb, CeilingLight, CeilingLightPro:
+		return true
+	}
+	return false
+}
+
+// IsCurtain returns true if the device type is one of window covering devices,
+// i.e. curtains, blind tilts and roller shades.
+func (typ PhysicalDeviceType) IsCurtain() bool {
+	switch typ {
+	case Curtain, BlindTilt, RollerShade:
+		return true
+	}
+	return false
+}
+
+// IsVacuumCleaner returns true if the device type is one of robot vacuum cleaners.
+func (typ PhysicalDeviceType) IsVacuumCleaner() bool {
+	switch typ {
+	case RobotVacuumCleanerS1, RobotVacuumCleanerS1Plus, WoSweeperMini, RobotVacuumCleanerK10PlusPro, RobotVacuumCleanerS10:
+		return true
+	}
+	return false
+}
+
+// SupportsColor returns true if the device type accepts SetColorCommand.
+func (typ PhysicalDeviceType) SupportsColor() bool {
+	switch typ {
+	case StripLight, ColorBulb:
+		return true
+	}
+	return false
+}
+
+// SupportsColorTemperature returns true if the device type accepts SetColorTemperatureCommand.
+func (typ PhysicalDeviceType) SupportsColorTemperature() bool {
+	switch typ {
+	case ColorBulb, CeilingLight, CeilingLightPro:
+		return true
+	}
+	return false
+}
+
+// SupportsSetPosition returns true if the device type accepts setPosition command,
+// e.g. CurtainSetPositionCommand, BlindTiltSetPositionCommand or RollerShadeSetPositionCommand.
+func (typ PhysicalDeviceType) SupportsSetPosition() bool {
+	return typ.IsCurtain()
+}
+
 type VirtualDeviceType string
 
 const (
@@ -136,11 +320,16 @@ const (
 // for getting openToken for SwitchBot API.
 func New(openToken, secretKey string, opts ...Option) *Client {
 	c := &Client{
-		httpClient: http.DefaultClient,
+		httpClient: newDefaultHTTPClient(),
 
 		openToken: openToken,
 		secretKey: secretKey,
 		endpoint:  DefaultEndpoint,
+		userAgent: DefaultUserAgent,
+		logger:    log.Default(),
+
+		nonceFunc: func() string { return uuid.New().String() },
+		clock:     time.Now,
 	}
 
 	c.deviceService = newDeviceService(c)
@@ -155,8 +344,13 @@ func New(openToken, secretKey string, opts ...Option) *Client {
 }
 
 // WithHTTPClient allows you to pass your http client for a SwitchBot API client.
+// The given client is used as is, instead of the default client which times out
+// after DefaultTimeout. nil is ignored.
 func WithHTTPClient(httpClient *http.Client) Option {
 	return func(c *Client) {
+		if httpClient == nil {
+			return
+		}
 		c.httpClient = httpClient
 	}
 }
@@ -168,6 +362,14 @@ func WithEndpoint(endpoint string) Option {
 	}
 }
 
+// WithUserAgent allows you to set a User-Agent header value sent with each request
+// so that API calls from your integration can be identified.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
 // WithDebug configures the client to print debug logs.
 func WithDebug() Option {
 	return func(c *Client) {
@@ -175,9 +377,147 @@ func WithDebug() Option {
 	}
 }
 
+// WithStructuredDebug configures the client to print debug logs as key/value fields,
+// method, path, HTTP status, and decoded statusCode and message of SwitchBot API
+// response, instead of the raw HTTP dump printed by WithDebug.
+func WithStructuredDebug() Option {
+	return func(c *Client) {
+		c.structuredDebug = true
+	}
+}
+
+// WithLogger allows you to set a logger used for printing debug logs.
+// By default, the standard logger of log package is used.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithoutBodyDrain configures the client not to read the remaining response body
+// before closing it. By default, the remaining body is read to the end so that the
+// underlying connection can be reused, but this may be wasteful for huge bodies
+// when the connection reuse is not needed.
+func WithoutBodyDrain() Option {
+	return func(c *Client) {
+		c.withoutBodyDrain = true
+	}
+}
+
+// WithTimeout configures the client to time out each request after given duration
+// when the context passed by the caller has no deadline. The default http client
+// also times out after DefaultTimeout regardless of this option.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithAutoRefreshDevices configures the client to remember the device IDs
+// returned by the device list API and to refresh the list once when Status or
+// Command is called with an unknown device ID. If the ID is still unknown after
+// the refresh, ErrDeviceNotFound is returned without calling the API.
+// The list is refreshed at most once a minute, and unknown IDs fail with
+// ErrDeviceNotFound without refreshing until then.
+func WithAutoRefreshDevices() Option {
+	return func(c *Client) {
+		c.autoRefreshDevices = true
+	}
+}
+
+// WithSchemaDriftLogging configures the client to log the JSON keys which are not
+// mapped to any field of DeviceStatus or webhook event contexts using the logger.
+// This is useful to notice new fields added to SwitchBot API.
+func WithSchemaDriftLogging() Option {
+	return func(c *Client) {
+		c.logSchemaDrift = true
+	}
+}
+
+// WithRetry configures the client to retry each request up to maxRetries times
+// when the server responds 429 Too Many Requests. GET requests are also retried when
+// they fail with a network error or the server responds 5xx, while other requests
+// such as device commands are not, as they may have been processed and retrying them
+// could repeat their effect, e.g. pressing a Bot twice. The client waits backoff before
+// the first retry, and the waiting duration is doubled for each following retry up to
+// one minute.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// maxRetryBackoff is the maximum duration to wait before a retry.
+const maxRetryBackoff = time.Minute
+
+// retryBackoff returns the duration to wait before the retry following given attempt,
+// which is base doubled for each attempt and capped at maxRetryBackoff.
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 0; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+
+	return backoff
+}
+
+// WithRetryBudget limits the total number of retries configured by WithRetry across
+// all requests of the client, so that retries do not storm the API during an outage.
+// The budget starts with capacity retries and regains one retry every refill duration
+// up to capacity. When the budget is exhausted, failed requests are not retried.
+// If refill is zero, the budget is never regained.
+func WithRetryBudget(capacity int, refill time.Duration) Option {
+	return func(c *Client) {
+		c.retryBudget = &retryBudget{
+			tokens:   capacity,
+			capacity: capacity,
+			refill:   refill,
+		}
+	}
+}
+
+// WithTraceIDKey configures the client to include the value of given context key
+// in each log line as traceID, so that the logs can be correlated with the caller's
+// tracing. Nothing is added when the context has no value for the key.
+func WithTraceIDKey(key interface{}) Option {
+	return func(c *Client) {
+		c.traceIDKey = key
+	}
+}
+
+// WithNonceFunc allows you to set a function generating the nonce used to sign
+// requests. By default, a random UUID is used. This is mainly for deterministic tests.
+// nil is ignored.
+func WithNonceFunc(fn func() string) Option {
+	return func(c *Client) {
+		if fn != nil {
+			c.nonceFunc = fn
+		}
+	}
+}
+
+// WithClock allows you to set a function returning the current time, which is
+// used as the timestamp to sign requests. By default, time.Now is used.
+// This is mainly for deterministic tests. nil is ignored.
+func WithClock(clock func() time.Time) Option {
+	return func(c *Client) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
 // httpResponse wraps a http.Response object to easily decode and close its response body.
 type httpResponse struct {
 	*http.Response
+
+	withoutDrain bool
+	// cancel releases the context derived for WithTimeout, if any.
+	cancel context.CancelFunc
 }
 
 func (resp *httpResponse) DecodeJSON(data interface{}) error {
@@ -189,17 +529,95 @@ func (resp *httpResponse) DecodeJSON(data interface{}) error {
 }
 
 func (resp *httpResponse) Close() {
-	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if !resp.withoutDrain {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+	}
 	_ = resp.Body.Close()
+
+	if resp.cancel != nil {
+		resp.cancel()
+	}
 }
 
-func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*httpResponse, error) {
-	nonce := uuid.New().String()
-	t := strconv.FormatInt(time.Now().UnixMilli(), 10)
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (_ *httpResponse, err error) {
+	cancel := func() {}
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	// the context is cancelled when the returned response is closed
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	var payload []byte
+	if body != nil {
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		resp, err = c.send(ctx, method, path, payload)
+
+		if attempt >= c.maxRetries || !isRetryable(ctx, method, resp, err) || !c.retryBudget.take(c.clock()) {
+			break
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(retryBackoff(c.retryBackoff, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// based on https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#standard-http-error-codes
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return nil, errors.New("client has issues an invalid request")
+	case http.StatusUnauthorized:
+		return nil, errors.New("authorization for the API is required but the request has not been authenticated")
+	case http.StatusForbidden:
+		return nil, errors.New("the request has been authenticated but does not have permission or the resource is not found")
+	case http.StatusNotAcceptable:
+		return nil, errors.New("the client has requestd a MIM typ via the Accept header for a value not supported by the server")
+	case http.StatusUnsupportedMediaType:
+		return nil, errors.New("the client has defined a Content-Type header that is not supported by the server")
+	case http.StatusUnprocessableEntity:
+		return nil, errors.New("the client has made a valid request but the server cannot process it")
+	case http.StatusTooManyRequests:
+		return nil, errors.New("the client has exceeded the number of requests allowed for a givn time window")
+	case http.StatusInternalServerError:
+		return nil, errors.New("an unexpected error on the server has occurred")
+	}
+
+	return &httpResponse{Response: resp, withoutDrain: c.withoutBodyDrain, cancel: cancel}, nil
+}
+
+// send signs and sends a request once, printing debug logs if configured.
+func (c *Client) send(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	nonce := c.nonceFunc()
+	t := strconv.FormatInt(c.clock().UnixMilli(), 10)
 	sign := hmacSHA256String(c.openToken+t+nonce, c.secretKey)
 
-	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, body)
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
 
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, body)
 	if err != nil {
 		return nil, err
 	}
@@ -209,13 +627,14 @@ func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*
 	req.Header.Add("nonce", nonce)
 	req.Header.Add("t", t)
 	req.Header.Add("Content-Type", "application/json; charset=utf8")
+	req.Header.Set("User-Agent", c.userAgent)
 
 	if c.debug {
 		dump, err := httputil.DumpRequestOut(req, true)
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("Request:\n%s\n", dump)
+		c.logger.Printf("Request:%s\n%s\n", c.traceField(ctx), dump)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -228,30 +647,99 @@ func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("Response:\n%s\n", dump)
+		c.logger.Printf("Response:%s\n%s\n", c.traceField(ctx), dump)
 	}
 
-	// based on https://github.com/OpenWonderLabs/SwitchBotAPI/blob/7a68353d84d07d439a11cb5503b634f24302f733/README.md#standard-http-error-codes
-	switch resp.StatusCode {
-	case http.StatusBadRequest:
-		return nil, errors.New("client has issues an invalid request")
-	case http.StatusUnauthorized:
-		return nil, errors.New("authorization for the API is required but the request has not been authenticated")
-	case http.StatusForbidden:
-		return nil, errors.New("the request has been authenticated but does not have permission or the resource is not found")
-	case http.StatusNotAcceptable:
-		return nil, errors.New("the client has requestd a MIM typ via the Accept header for a value not supported by the server")
-	case http.StatusUnsupportedMediaType:
-		return nil, errors.New("the client has defined a Content-Type header that is not supported by the server")
-	case http.StatusUnprocessableEntity:
-		return nil, errors.New("the client has made a valid request but the server cannot process it")
-	case http.StatusTooManyRequests:
-		return nil, errors.New("the client has exceeded the number of requests allowed for a givn time window")
-	case http.StatusInternalServerError:
-		return nil, errors.New("an unexpected error on the server has occurred")
+	if c.structuredDebug {
+		if err := c.logStructured(req, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// isRetryable reports whether the request should be retried. GET requests are retried
+// when they failed without being cancelled by the caller, or the server is busy or
+// failing. Other requests, e.g. device commands, may have been processed on those
+// failures and sending them again can repeat their effect, e.g. toggling a plug back,
+// so they are retried only on 429 Too Many Requests, which is not processed.
+func isRetryable(ctx context.Context, method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return method == http.MethodGet && ctx.Err() == nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	return method == http.MethodGet && resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryBudget is a token bucket shared by all requests of a client to limit
+// the total number of retries. A nil retryBudget allows any retries.
+type retryBudget struct {
+	mu       sync.Mutex
+	tokens   int
+	capacity int
+	refill   time.Duration
+	last     time.Time
+}
+
+// take consumes a token if available. A token is added every refill duration
+// up to the capacity.
+func (b *retryBudget) take(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last.IsZero() {
+		b.last = now
+	}
+	if b.refill > 0 {
+		if n := int(now.Sub(b.last) / b.refill); n > 0 {
+			b.tokens += n
+			if b.tokens > b.capacity {
+				b.tokens = b.capacity
+			}
+			b.last = b.last.Add(time.Duration(n) * b.refill)
+		}
+	}
+
+	if b.tokens <= 0 {
+		return false
 	}
+	b.tokens--
 
-	return &httpResponse{Response: resp}, nil
+	return true
+}
+
+// logStructured prints given request and response as key/value fields.
+// The response body is restored after reading so that the caller can decode it.
+func (c *Client) logStructured(req *http.Request, resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var response struct {
+		StatusCode int    `json:"statusCode"`
+		Message    string `json:"message"`
+	}
+	// the body is not always a SwitchBot API response, e.g. when errors occur in the
+	// middle of proxies, so decoding errors are ignored here
+	_ = json.Unmarshal(body, &response)
+
+	c.logger.Printf("method=%s path=%s status=%d statusCode=%d message=%q%s",
+		req.Method, req.URL.Path, resp.StatusCode, response.StatusCode, response.Message, c.traceField(req.Context()),
+	)
+
+	return nil
 }
 
 func (c *Client) get(ctx context.Context, path string) (*httpResponse, error) {
@@ -278,8 +766,117 @@ func (c *Client) del(ctx context.Context, path string, body interface{}) (*httpR
 	return c.do(ctx, http.MethodDelete, path, &buf)
 }
 
+// logUnmappedKeys logs the keys of extra when WithSchemaDriftLogging is given.
+func (c *Client) logUnmappedKeys(ctx context.Context, source, deviceType string, extra map[string]json.RawMessage) {
+	if !c.logSchemaDrift || len(extra) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	c.logger.Printf("unmapped keys found: source=%s deviceType=%s keys=%s%s", source, deviceType, strings.Join(keys, ","), c.traceField(ctx))
+}
+
+// traceField returns " traceID=<value>" to be appended to log lines when
+// WithTraceIDKey is given and ctx has a value for the key, otherwise empty string.
+func (c *Client) traceField(ctx context.Context) string {
+	if c.traceIDKey == nil {
+		return ""
+	}
+
+	value := ctx.Value(c.traceIDKey)
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(" traceID=%v", value)
+}
+
 func hmacSHA256String(message, key string) string {
 	signer := hmac.New(sha256.New, []byte(key))
 	signer.Write([]byte(message))
 	return strings.ToUpper(base64.StdEncoding.EncodeToString(signer.Sum(nil)))
 }
+
+// unmarshalWithExtra decodes given JSON object into v, which must be a pointer to
+// a struct, and returns the values whose keys are not mapped to any field of v.
+// The returned map is nil if all the keys are known.
+func unmarshalWithExtra(b []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(v).Elem())
+
+	var extra map[string]json.RawMessage
+	for key, value := range raw {
+		if isKnownJSONKey(key, known) {
+			continue
+		}
+
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+
+	return extra, nil
+}
+
+// jsonFieldNames returns JSON object keys for the fields of given struct type.
+// As same as encoding/json, the fields of embedded structs without JSON tag are promoted.
+func jsonFieldNames(typ reflect.Type) []string {
+	var names []string
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				names = append(names, jsonFieldNames(embedded)...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// isKnownJSONKey reports whether given key matches one of known names.
+// As same as encoding/json, the keys are matched case-insensitively.
+func isKnownJSONKey(key string, known []string) bool {
+	for _, name := range known {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+
+	return false
+}
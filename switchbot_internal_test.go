@@ -0,0 +1,79 @@
+package switchbot
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDefaultHTTPClient(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		c := New("", "")
+
+		if c.httpClient.Timeout != DefaultTimeout {
+			t.Errorf("unexpected timeout: %s != %s", c.httpClient.Timeout, DefaultTimeout)
+		}
+
+		transport, ok := c.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("transport should be *http.Transport but %T", c.httpClient.Transport)
+		}
+		if transport == http.DefaultTransport {
+			t.Error("http.DefaultTransport must not be shared")
+		}
+		if transport.MaxIdleConnsPerHost != 10 {
+			t.Errorf("unexpected max idle connections per host: %d", transport.MaxIdleConnsPerHost)
+		}
+
+		if other := New("", ""); other.httpClient.Transport == c.httpClient.Transport {
+			t.Error("transport must be dedicated to each client")
+		}
+	})
+
+	t.Run("with timeout", func(t *testing.T) {
+		c := New("", "", WithTimeout(time.Second))
+
+		if c.timeout != time.Second {
+			t.Errorf("unexpected request timeout: %s", c.timeout)
+		}
+		if c.httpClient.Timeout != DefaultTimeout {
+			t.Errorf("http client timeout should be kept: %s", c.httpClient.Timeout)
+		}
+	})
+
+	t.Run("with http client", func(t *testing.T) {
+		httpClient := &http.Client{}
+		c := New("", "", WithHTTPClient(httpClient))
+
+		if c.httpClient != httpClient {
+			t.Error("given http client should be used as is")
+		}
+		if c.httpClient.Timeout != 0 {
+			t.Errorf("default timeout must not be applied to given http client: %s", c.httpClient.Timeout)
+		}
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		label   string
+		base    time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{label: "first retry", base: 100 * time.Millisecond, attempt: 0, want: 100 * time.Millisecond},
+		{label: "doubled", base: 100 * time.Millisecond, attempt: 3, want: 800 * time.Millisecond},
+		{label: "capped", base: 100 * time.Millisecond, attempt: 10, want: maxRetryBackoff},
+		{label: "no overflow", base: time.Second, attempt: 100, want: maxRetryBackoff},
+		{label: "base over maximum", base: time.Hour, attempt: 0, want: maxRetryBackoff},
+		{label: "zero", base: 0, attempt: 100, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if got := retryBackoff(tt.base, tt.attempt); got != tt.want {
+				t.Errorf("unexpected backoff: %s != %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,620 @@
+package switchbot_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nasa9084/go-switchbot/v4"
+)
+
+func TestUserAgent(t *testing.T) {
+	if !strings.HasPrefix(switchbot.DefaultUserAgent, "go-switchbot/v4") {
+		t.Errorf("default user agent should have the module version but %s", switchbot.DefaultUserAgent)
+	}
+
+	tests := []struct {
+		label string
+		opts  []switchbot.Option
+		want  string
+	}{
+		{
+			label: "default",
+			want:  switchbot.DefaultUserAgent,
+		},
+		{
+			label: "custom",
+			opts:  []switchbot.Option{switchbot.WithUserAgent("my-integration/1.0")},
+			want:  "my-integration/1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if got := r.Header.Get("User-Agent"); got != tt.want {
+						t.Errorf("unexpected User-Agent header: %s != %s", got, tt.want)
+					}
+
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+				}),
+			)
+			defer srv.Close()
+
+			opts := append([]switchbot.Option{switchbot.WithEndpoint(srv.URL)}, tt.opts...)
+			c := switchbot.New("", "", opts...)
+
+			if _, err := c.Scene().List(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStructuredDebug(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"statusCode":100,"body":[{"sceneId":"T02-20200804130110","sceneName":"Close Office Devices"}],"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	c := switchbot.New("", "",
+		switchbot.WithEndpoint(srv.URL),
+		switchbot.WithStructuredDebug(),
+		switchbot.WithLogger(log.New(&buf, "", 0)),
+	)
+
+	scenes, err := c.Scene().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scenes) != 1 {
+		t.Errorf("response body should be decodable after logging but got %d scenes", len(scenes))
+	}
+
+	want := `method=GET path=/v1.1/scenes status=200 statusCode=100 message="success"`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("unexpected log output:\n  got:  %s\n  want: %s", got, want)
+	}
+}
+
+// countingRoundTripper returns a response whose body is given JSON followed by many spaces,
+// and counts the number of bytes read from the body.
+type countingRoundTripper struct {
+	json    string
+	padding int
+
+	read int
+}
+
+func (rt *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := io.MultiReader(strings.NewReader(rt.json), strings.NewReader(strings.Repeat(" ", rt.padding)))
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(&countingReader{r: body, n: &rt.read}),
+		Request:    req,
+	}, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	*cr.n += n
+	return n, err
+}
+
+func TestWithoutBodyDrain(t *testing.T) {
+	const body = `{"statusCode":100,"body":[],"message":"success"}`
+	const padding = 1 << 20
+
+	tests := []struct {
+		label    string
+		opts     []switchbot.Option
+		wantFull bool
+	}{
+		{
+			label:    "default",
+			wantFull: true,
+		},
+		{
+			label:    "without body drain",
+			opts:     []switchbot.Option{switchbot.WithoutBodyDrain()},
+			wantFull: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			rt := &countingRoundTripper{json: body, padding: padding}
+
+			opts := append([]switchbot.Option{switchbot.WithHTTPClient(&http.Client{Transport: rt})}, tt.opts...)
+			c := switchbot.New("", "", opts...)
+
+			if _, err := c.Scene().List(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if full := rt.read == len(body)+padding; full != tt.wantFull {
+				t.Errorf("unexpected number of bytes read from body: %d of %d", rt.read, len(body)+padding)
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, "slow") {
+				select {
+				case <-r.Context().Done():
+				case <-done:
+				}
+				return
+			}
+
+			w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+	defer close(done)
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL), switchbot.WithTimeout(50*time.Millisecond))
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		err := c.Scene().Execute(context.Background(), "slow")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("context.DeadlineExceeded is expected but got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("request took too long: %s", elapsed)
+		}
+	})
+
+	t.Run("body is readable after response", func(t *testing.T) {
+		if _, err := c.Scene().List(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestWithHTTPClientNil(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+
+	// nil http client must be ignored so that the default client is used
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL), switchbot.WithHTTPClient(nil))
+
+	if _, err := c.Scene().List(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaDriftLogging(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"statusCode":100,"body":{"deviceId":"C271111EC0AB","deviceType":"Meter","hubDeviceId":"FA7310762361","humidity":52,"temperature":26.1,"newField":1,"anotherField":"x"},"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+
+	const webhookBody = `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789,"newField":1}}`
+
+	tests := []struct {
+		label string
+		opts  []switchbot.Option
+		want  []string
+	}{
+		{
+			label: "disabled",
+		},
+		{
+			label: "enabled",
+			opts:  []switchbot.Option{switchbot.WithSchemaDriftLogging()},
+			want: []string{
+				"unmapped keys found: source=device status deviceType=Meter keys=anotherField,newField",
+				"unmapped keys found: source=webhook event deviceType=WoMeter keys=newField",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := append([]switchbot.Option{switchbot.WithEndpoint(srv.URL), switchbot.WithLogger(log.New(&buf, "", 0))}, tt.opts...)
+			c := switchbot.New("", "", opts...)
+
+			if _, err := c.Device().Status(context.Background(), "C271111EC0AB"); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(webhookBody))
+			if _, err := c.Webhook().ParseRequest(req); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			if out := strings.TrimSpace(buf.String()); out != "" {
+				got = strings.Split(out, "\n")
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("unexpected number of log lines: %d != %d\n%s", len(got), len(tt.want), buf.String())
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("unexpected log output:\n  got:  %s\n  want: %s", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPhysicalDeviceTypePredicates(t *testing.T) {
+	type predicates struct {
+		Hub, Meter, Plug, Light, Curtain, VacuumCleaner bool
+		Color, ColorTemperature, SetPosition            bool
+	}
+
+	tests := []struct {
+		typ  switchbot.PhysicalDeviceType
+		want predicates
+	}{
+		{typ: switchbot.Hub, want: predicates{Hub: true}},
+		{typ: switchbot.HubPlus, want: predicates{Hub: true}},
+		{typ: switchbot.HubMini, want: predicates{Hub: true}},
+		{typ: switchbot.Hub2, want: predicates{Hub: true}},
+		{typ: switchbot.Hub3, want: predicates{Hub: true}},
+		{typ: switchbot.Bot},
+		{typ: switchbot.Curtain, want: predicates{Curtain: true, SetPosition: true}},
+		{typ: switchbot.Plug, want: predicates{Plug: true}},
+		{typ: switchbot.Meter, want: predicates{Meter: true}},
+		{typ: switchbot.MeterPlusJP, want: predicates{Meter: true}},
+		{typ: switchbot.MeterPlusUS, want: predicates{Meter: true}},
+		{typ: switchbot.WoIOSensor, want: predicates{Meter: true}},
+		{typ: switchbot.Humidifier},
+		{typ: switchbot.SmartFan},
+		{typ: switchbot.BatteryCirculatorFan},
+		{typ: switchbot.StripLight, want: predicates{Light: true, Color: true}},
+		{typ: switchbot.PlugMiniUS, want: predicates{Plug: true}},
+		{typ: switchbot.PlugMiniJP, want: predicates{Plug: true}},
+		{typ: switchbot.RelaySwitch1PM},
+		{typ: switchbot.RelaySwitch1},
+		{typ: switchbot.Lock},
+		{typ: switchbot.LockPro},
+		{typ: switchbot.RobotVacuumCleanerS1, want: predicates{VacuumCleaner: true}},
+		{typ: switchbot.RobotVacuumCleanerS1Plus, want: predicates{VacuumCleaner: true}},
+		{typ: switchbot.WoSweeperMini, want: predicates{VacuumCleaner: true}},
+		{typ: switchbot.RobotVacuumCleanerK10PlusPro, want: predicates{VacuumCleaner: true}},
+		{typ: switchbot.RobotVacuumCleanerS10, want: predicates{VacuumCleaner: true}},
+		{typ: switchbot.MotionSensor},
+		{typ: switchbot.ContactSensor},
+		{typ: switchbot.WaterDetector},
+		{typ: switchbot.ColorBulb, want: predicates{Light: true, Color: true, ColorTemperature: true}},
+		{typ: switchbot.MeterPlus, want: predicates{Meter: true}},
+		{typ: switchbot.KeyPad},
+		{typ: switchbot.KeyPadTouch},
+		{typ: switchbot.CeilingLight, want: predicates{Light: true, ColorTemperature: true}},
+		{typ: switchbot.CeilingLightPro, want: predicates{Light: true, ColorTemperature: true}},
+		{typ: switchbot.IndoorCam},
+		{typ: switchbot.PanTiltCam},
+		{typ: switchbot.PanTiltCam2K},
+		{typ: switchbot.VideoDoorbell},
+		{typ: switchbot.BlindTilt, want: predicates{Curtain: true, SetPosition: true}},
+		{typ: switchbot.RollerShade, want: predicates{Curtain: true, SetPosition: true}},
+		{typ: switchbot.MeterPro, want: predicates{Meter: true}},
+		{typ: switchbot.MeterProCO2, want: predicates{Meter: true}},
+		{typ: switchbot.AirPurifierVOC},
+		{typ: switchbot.AirPurifierTableVOC},
+		{typ: switchbot.AirPurifierPM25},
+		{typ: switchbot.AirPurifierTablePM25},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.typ), func(t *testing.T) {
+			got := predicates{
+				Hub:              tt.typ.IsHub(),
+				Meter:            tt.typ.IsMeter(),
+				Plug:             tt.typ.IsPlug(),
+				Light:            tt.typ.IsLight(),
+				Curtain:          tt.typ.IsCurtain(),
+				VacuumCleaner:    tt.typ.IsVacuumCleaner(),
+				Color:            tt.typ.SupportsColor(),
+				ColorTemperature: tt.typ.SupportsColorTemperature(),
+				SetPosition:      tt.typ.SupportsSetPosition(),
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected predicates:\n  got:  %+v\n  want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithNonceFuncAndClock(t *testing.T) {
+	want := map[string]string{
+		"Authorization": "token",
+		"nonce":         "fixed-nonce",
+		"t":             "1700000000000",
+		// base64 encoded HMAC-SHA256 of "token" + "1700000000000" + "fixed-nonce" with key "secret", in upper case
+		"sign": "W2ITUG2WL2DI6KMFEZXFOOSSPPNAO14WA8LTM3BZ20G=",
+	}
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for key, value := range want {
+				if got := r.Header.Get(key); got != value {
+					t.Errorf("unexpected %s header: %s != %s", key, got, value)
+				}
+			}
+
+			w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("token", "secret",
+		switchbot.WithEndpoint(srv.URL),
+		switchbot.WithNonceFunc(func() string { return "fixed-nonce" }),
+		switchbot.WithClock(func() time.Time { return time.UnixMilli(1700000000000) }),
+	)
+
+	if _, err := c.Scene().List(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		label     string
+		status    int
+		send      func(c *switchbot.Client) error
+		wantCalls int
+	}{
+		{
+			label:  "get is retried on 5xx",
+			status: http.StatusInternalServerError,
+			send: func(c *switchbot.Client) error {
+				_, err := c.Scene().List(context.Background())
+				return err
+			},
+			wantCalls: 3,
+		},
+		{
+			label:  "command is retried on 429",
+			status: http.StatusTooManyRequests,
+			send: func(c *switchbot.Client) error {
+				return c.Device().Command(context.Background(), "6055F930FF22", switchbot.ToggleCommand())
+			},
+			wantCalls: 3,
+		},
+		{
+			label:  "command is not retried on 5xx",
+			status: http.StatusInternalServerError,
+			send: func(c *switchbot.Client) error {
+				return c.Device().Command(context.Background(), "6055F930FF22", switchbot.ToggleCommand())
+			},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var calls int
+
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls++
+					if calls <= 2 {
+						w.WriteHeader(tt.status)
+						return
+					}
+
+					w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL), switchbot.WithRetry(3, time.Millisecond))
+
+			err := tt.send(c)
+			if tt.wantCalls == 3 && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantCalls == 1 && err == nil {
+				t.Fatal("error is expected as the command is not retried")
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("the request should be sent %d times but %d times", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestWithRetryBudget(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	defer srv.Close()
+
+	const (
+		requests   = 10
+		maxRetries = 3
+		budget     = 5
+	)
+
+	c := switchbot.New("", "",
+		switchbot.WithEndpoint(srv.URL),
+		switchbot.WithRetry(maxRetries, time.Millisecond),
+		switchbot.WithRetryBudget(budget, 0),
+	)
+
+	for i := 0; i < requests; i++ {
+		if _, err := c.Scene().List(context.Background()); err == nil {
+			t.Fatal("error is expected")
+		}
+	}
+
+	// without the budget, the requests would be sent requests * (1 + maxRetries) times
+	if want := requests + budget; calls != want {
+		t.Errorf("the total number of requests should be capped by the budget: %d != %d", calls, want)
+	}
+}
+
+func TestRequestSigning(t *testing.T) {
+	// the signature itself is checked against a precomputed value by TestWithNonceFuncAndClock
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	// base64 encoded 32 bytes of HMAC-SHA256, in upper case
+	signPattern := regexp.MustCompile(`^[0-9A-Z+/]{43}=$`)
+
+	var mu sync.Mutex
+	nonces := make(map[string]bool)
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+
+			nonce := r.Header.Get("nonce")
+			if !uuidPattern.MatchString(nonce) {
+				t.Errorf("nonce header should be a UUID but %q", nonce)
+			}
+			mu.Lock()
+			if nonces[nonce] {
+				t.Errorf("nonce must not be reused: %s", nonce)
+			}
+			nonces[nonce] = true
+			mu.Unlock()
+
+			ts := r.Header.Get("t")
+			if len(ts) != 13 {
+				t.Errorf("t header should be a 13 digits timestamp in milliseconds but %q", ts)
+			}
+			ms, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				t.Errorf("t header should be a timestamp in milliseconds but %q", ts)
+			} else if d := time.Since(time.UnixMilli(ms)); d < 0 || d > time.Minute {
+				t.Errorf("t header should be the current time but %s", time.UnixMilli(ms))
+			}
+
+			if got := r.Header.Get("sign"); !signPattern.MatchString(got) {
+				t.Errorf("sign header should be an upper cased base64 encoded HMAC-SHA256 but %q", got)
+			}
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("token", "secret", switchbot.WithEndpoint(srv.URL))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Scene().List(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type traceIDKey struct{}
+
+func TestWithTraceIDKey(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"statusCode":100,"body":[],"message":"success"}`))
+		}),
+	)
+	defer srv.Close()
+
+	tests := []struct {
+		label string
+		ctx   context.Context
+		want  string
+	}{
+		{
+			label: "with trace id",
+			ctx:   context.WithValue(context.Background(), traceIDKey{}, "4bf92f3577b34da6"),
+			want:  `method=GET path=/v1.1/scenes status=200 statusCode=100 message="success" traceID=4bf92f3577b34da6`,
+		},
+		{
+			label: "without trace id",
+			ctx:   context.Background(),
+			want:  `method=GET path=/v1.1/scenes status=200 statusCode=100 message="success"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			var buf bytes.Buffer
+			c := switchbot.New("", "",
+				switchbot.WithEndpoint(srv.URL),
+				switchbot.WithStructuredDebug(),
+				switchbot.WithLogger(log.New(&buf, "", 0)),
+				switchbot.WithTraceIDKey(traceIDKey{}),
+			)
+
+			if _, err := c.Scene().List(tt.ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("unexpected log output:\n  got:  %s\n  want: %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("debug dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		c := switchbot.New("", "",
+			switchbot.WithEndpoint(srv.URL),
+			switchbot.WithDebug(),
+			switchbot.WithLogger(log.New(&buf, "", 0)),
+			switchbot.WithTraceIDKey(traceIDKey{}),
+		)
+
+		ctx := context.WithValue(context.Background(), traceIDKey{}, "4bf92f3577b34da6")
+		if _, err := c.Scene().List(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{"Request: traceID=4bf92f3577b34da6\n", "Response: traceID=4bf92f3577b34da6\n"} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("log output should contain %q:\n%s", want, buf.String())
+			}
+		}
+	})
+}
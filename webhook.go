@@ -8,6 +8,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
 type WebhookService struct {
@@ -25,7 +29,7 @@ func (c *Client) Webhook() *WebhookService {
 type webhookSetupRequest struct {
 	Action     string `json:"action"`
 	URL        string `json:"url,omitempty"`
-	DeviceList string `json:"deviceList,omitempty"` // currently only ALL is supported
+	DeviceList string `json:"deviceList,omitempty"`
 }
 
 type webhookSetupResponse struct {
@@ -35,18 +39,23 @@ type webhookSetupResponse struct {
 }
 
 // Setup configures the url that all the webhook events will be sent to.
-// Currently the deviceList is only supporting "ALL".
-func (svc *WebhookService) Setup(ctx context.Context, url, deviceList string) error {
+// deviceList is a list of device IDs whose events are sent to the url.
+// When no device ID is given, "ALL" is used, which means events of all the devices are sent.
+func (svc *WebhookService) Setup(ctx context.Context, url string, deviceList ...string) error {
 	const path = "/v1.1/webhook/setupWebhook"
 
-	if deviceList != "ALL" {
-		return errors.New(`deviceList value is only supporting "ALL" for now`)
+	if url == "" {
+		return errors.New("URL need to be specified for setting up webhook")
+	}
+
+	if len(deviceList) == 0 {
+		deviceList = []string{"ALL"}
 	}
 
 	req := webhookSetupRequest{
 		Action:     "setupWebhook",
 		URL:        url,
-		DeviceList: deviceList,
+		DeviceList: strings.Join(deviceList, ","),
 	}
 
 	resp, err := svc.c.post(ctx, path, req)
@@ -55,6 +64,17 @@ func (svc *WebhookService) Setup(ctx context.Context, url, deviceList string) er
 	}
 	defer resp.Close()
 
+	var response webhookSetupResponse
+	if err := resp.DecodeJSON(&response); err != nil {
+		return err
+	}
+
+	if response.StatusCode == 190 {
+		return fmt.Errorf("undocumented error %d occurred for setupWebhook API: %s", response.StatusCode, response.Message)
+	} else if response.StatusCode != 100 {
+		return fmt.Errorf("unknown error %d from setupWebhook API: %s", response.StatusCode, response.Message)
+	}
+
 	return nil
 }
 
@@ -70,9 +90,6 @@ const (
 	QueryDetails WebhookQueryActionType = "queryDetails"
 )
 
-type webhookQueryResponse struct {
-}
-
 type webhookQueryUrlResponse struct {
 	StatusCode int                         `json:"statusCode"`
 	Message    string                      `json:"message"`
@@ -97,35 +114,41 @@ type WebhookQueryDetails struct {
 	Enable     bool   `json:"enable"`
 }
 
+// WebhookQueryResult is a result of Query.
+// URLs is set for QueryURL action, and Details is set for QueryDetails action.
+type WebhookQueryResult struct {
+	URLs    []string
+	Details []WebhookQueryDetails
+}
+
 // Query retrieves the current configuration info of the webhook.
 // The second argument `url` is required for QueryDetails action type.
-func (svc *WebhookService) Query(ctx context.Context, action WebhookQueryActionType, url string) error {
-	const path = "/v1.1/webhook/queryWebhook"
-
-	req := webhookQueryRequest{
-		Action: action,
-	}
-
+func (svc *WebhookService) Query(ctx context.Context, action WebhookQueryActionType, url string) (*WebhookQueryResult, error) {
 	switch action {
+	case QueryURL:
+		urls, err := svc.queryURLs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return &WebhookQueryResult{URLs: urls}, nil
 	case QueryDetails:
 		if url == "" {
-			return errors.New("URL need to be specified when the action is queryDetails")
+			return nil, errors.New("URL need to be specified when the action is queryDetails")
 		}
 
-		req.URLs = []string{url}
-	}
+		details, err := svc.queryDetails(ctx, url)
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := svc.c.post(ctx, path, req)
-	if err != nil {
-		return err
+		return &WebhookQueryResult{Details: details}, nil
+	default:
+		return nil, fmt.Errorf("unknown action type for queryWebhook API: %s", action)
 	}
-	defer resp.Close()
-
-	return nil
 }
 
-// QueryUrl retrieves the current url configuration info of the webhook.
-func (svc *WebhookService) QueryUrl(ctx context.Context) (string, error) {
+func (svc *WebhookService) queryURLs(ctx context.Context) ([]string, error) {
 	const path = "/v1.1/webhook/queryWebhook"
 
 	req := webhookQueryRequest{
@@ -134,30 +157,25 @@ func (svc *WebhookService) QueryUrl(ctx context.Context) (string, error) {
 
 	resp, err := svc.c.post(ctx, path, req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Close()
 
 	var response webhookQueryUrlResponse
 	if err := resp.DecodeJSON(&response); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if response.StatusCode == 190 {
-		return "", fmt.Errorf("undocumented error %d occurred for queryWebhook API: %s", response.StatusCode, response.Message)
+		return nil, fmt.Errorf("undocumented error %d occurred for queryWebhook API: %s", response.StatusCode, response.Message)
 	} else if response.StatusCode != 100 {
-		return "", fmt.Errorf("unknown error %d from queryWebhook API: %s", response.StatusCode, response.Message)
-	}
-
-	if len(response.Body.URLs) < 1 {
-		return "", errors.New("queryWebhook API response urls is empty")
+		return nil, fmt.Errorf("unknown error %d from queryWebhook API: %s", response.StatusCode, response.Message)
 	}
 
-	return response.Body.URLs[0], nil
+	return response.Body.URLs, nil
 }
 
-// QueryDetails retrieves the current details configuration info of the webhook.
-func (svc *WebhookService) QueryDetails(ctx context.Context, url string) (*WebhookQueryDetails, error) {
+func (svc *WebhookService) queryDetails(ctx context.Context, url string) ([]WebhookQueryDetails, error) {
 	const path = "/v1.1/webhook/queryWebhook"
 
 	req := webhookQueryRequest{
@@ -182,11 +200,52 @@ func (svc *WebhookService) QueryDetails(ctx context.Context, url string) (*Webho
 		return nil, fmt.Errorf("unknown error %d from queryWebhook API: %s", response.StatusCode, response.Message)
 	}
 
-	if len(response.Body) < 1 {
+	return response.Body, nil
+}
+
+// QueryUrls retrieves all the urls configured for the webhook.
+// An empty slice is returned when no url is configured.
+func (svc *WebhookService) QueryUrls(ctx context.Context) ([]string, error) {
+	urls, err := svc.queryURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if urls == nil {
+		urls = []string{}
+	}
+
+	return urls, nil
+}
+
+// QueryUrl retrieves the current url configuration info of the webhook.
+// Only the first url is returned when multiple urls are configured; use QueryUrls
+// to get all of them.
+func (svc *WebhookService) QueryUrl(ctx context.Context) (string, error) {
+	urls, err := svc.queryURLs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if len(urls) < 1 {
+		return "", errors.New("queryWebhook API response urls is empty")
+	}
+
+	return urls[0], nil
+}
+
+// QueryDetails retrieves the current details configuration info of the webhook.
+func (svc *WebhookService) QueryDetails(ctx context.Context, url string) (*WebhookQueryDetails, error) {
+	details, err := svc.queryDetails(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(details) < 1 {
 		return nil, errors.New("queryWebhook API response body is empty")
 	}
 
-	return &response.Body[0], nil
+	return &details[0], nil
 }
 
 type webhookUpdateRequest struct {
@@ -244,20 +303,165 @@ func (svc *WebhookService) Delete(ctx context.Context, url string) error {
 }
 
 func deviceTypeFromWebhookRequest(r *http.Request) (string, error) {
+	head, err := webhookContextHeadFromRequest(r)
+	if err != nil {
+		return "", err
+	}
+
+	return head.DeviceType, nil
+}
+
+// ErrEmptyWebhookBody is returned when a webhook request has an empty or truncated body.
+var ErrEmptyWebhookBody = errors.New("webhook request body is empty or truncated")
+
+// webhookContextHead is a set of context values common to all the webhook events.
+type webhookContextHead struct {
+	DeviceType string `json:"deviceType"`
+	DeviceMac  string `json:"deviceMac"`
+}
+
+// webhookContextHeadFromRequest peeks the common context values of webhook request.
+// The request body is restored so that it can be read again.
+func webhookContextHeadFromRequest(r *http.Request) (webhookContextHead, error) {
 	var rawBody bytes.Buffer
-	var deviceTypeBody struct {
-		Context struct {
-			DeviceType string `json:"deviceType"`
-		} `json:"context"`
+	var body struct {
+		Context webhookContextHead `json:"context"`
+	}
+
+	if r.Body == nil {
+		return webhookContextHead{}, ErrEmptyWebhookBody
+	}
+
+	if err := json.NewDecoder(io.TeeReader(r.Body, &rawBody)).Decode(&body); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return webhookContextHead{}, ErrEmptyWebhookBody
+		}
+		return webhookContextHead{}, err
+	}
+
+	r.Body = io.NopCloser(&rawBody)
+
+	return body.Context, nil
+}
+
+// WebhookHandler returns a http.Handler which parses webhook requests using
+// ParseWebhookRequest and calls fn with the parsed event.
+// The request is acknowledged with {"statusCode":100} when fn returns nil.
+// 400 Bad Request is responded when the request cannot be parsed, and 500 Internal
+// Server Error is responded when fn returns an error, both with the error message.
+func WebhookHandler(fn func(WebhookEvent) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, err := ParseWebhookRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := fn(event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"statusCode":100}`))
+	})
+}
+
+// DebounceWebhook returns a http.Handler which drops duplicate webhook requests from the
+// same device, identified by the deviceMac of the event, within given interval.
+// A request is a duplicate when its context is the same as the last passed one except for
+// timeOfSample, so state changes, e.g. a contact sensor going open and then close, are
+// always passed to the next handler. Dropped requests are acknowledged with 200 OK,
+// which is useful for flapping sensors.
+func DebounceWebhook(next http.Handler, interval time.Duration) http.Handler {
+	type accepted struct {
+		at    time.Time
+		state string
 	}
 
-	if err := json.NewDecoder(io.TeeReader(r.Body, &rawBody)).Decode(&deviceTypeBody); err != nil {
+	var mu sync.Mutex
+	lastAccepted := map[string]accepted{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		head, err := webhookContextHeadFromRequest(r)
+		if err != nil {
+			// let the next handler report the malformed request
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		state, err := webhookStateFromRequest(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+
+		mu.Lock()
+		// forget the devices which no longer debounce so that the map does not grow
+		for mac, last := range lastAccepted {
+			if now.Sub(last.at) >= interval {
+				delete(lastAccepted, mac)
+			}
+		}
+		last, ok := lastAccepted[head.DeviceMac]
+		drop := ok && last.state == state
+		if !drop {
+			lastAccepted[head.DeviceMac] = accepted{at: now, state: state}
+		}
+		mu.Unlock()
+
+		if drop {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// webhookStateFromRequest returns the context of webhook request without timeOfSample,
+// in a canonical form to compare the states reported by events.
+// The request body is restored so that it can be read again.
+func webhookStateFromRequest(r *http.Request) (string, error) {
+	var rawBody bytes.Buffer
+	var body struct {
+		Context map[string]json.RawMessage `json:"context"`
+	}
+
+	if err := json.NewDecoder(io.TeeReader(r.Body, &rawBody)).Decode(&body); err != nil {
 		return "", err
 	}
 
 	r.Body = io.NopCloser(&rawBody)
 
-	return deviceTypeBody.Context.DeviceType, nil
+	delete(body.Context, "timeOfSample")
+	// the keys of maps are sorted by encoding/json
+	state, err := json.Marshal(body.Context)
+	if err != nil {
+		return "", err
+	}
+
+	return string(state), nil
+}
+
+// WebhookEvent is an interface implemented by all the webhook events, which provides
+// accessors to the context values common to all the events.
+type WebhookEvent interface {
+	GetDeviceType() string
+	GetDeviceMac() string
+	GetTimeOfSample() int64
+	// BatteryLow reports whether the battery level in the event is equal to or lower
+	// than threshold. This always returns false for the events without battery level.
+	BatteryLow(threshold int) bool
+}
+
+// batteryLow reports whether battery is equal to or lower than threshold.
+// A nil battery, which means the event does not have the battery level, is never low.
+func batteryLow(battery *int, threshold int) bool {
+	return battery != nil && *battery <= threshold
 }
 
 type MotionSensorEvent struct {
@@ -266,6 +470,13 @@ type MotionSensorEvent struct {
 	Context      MotionSensorEventContext `json:"context"`
 }
 
+func (event MotionSensorEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event MotionSensorEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event MotionSensorEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event MotionSensorEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
 type MotionSensorEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -273,15 +484,28 @@ type MotionSensorEventContext struct {
 
 	// the motion state of the device, "DETECTED" stands for motion is detected;
 	// "NOT_DETECTED" stands for motion has not been detected for some time
-	DetectionState string `json:"detectionState"`
+	DetectionState DetectionState `json:"detectionState"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *MotionSensorEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type ContactSensorEvent struct {
 	EventType    string                    `json:"eventType"`
 	EventVersion string                    `json:"eventVersion"`
 	Context      ContactSensorEventContext `json:"context"`
 }
 
+func (event ContactSensorEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event ContactSensorEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event ContactSensorEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event ContactSensorEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
 type ContactSensorEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -289,53 +513,266 @@ type ContactSensorEventContext struct {
 
 	// the motion state of the device, "DETECTED" stands for motion is detected;
 	// "NOT_DETECTED" stands for motion has not been detected for some time
-	DetectionState string `json:"detectionState"`
+	DetectionState DetectionState `json:"detectionState"`
 	// when the enter or exit mode gets triggered, "IN_DOOR" or "OUT_DOOR" is returned
-	DoorMode string `json:"doorMode"`
+	DoorMode DoorMode `json:"doorMode"`
 	// the level of brightness, can be "bright" or "dim"
 	Brightness AmbientBrightness `json:"brightness"`
 	// the state of the contact sensor, can be "open" or "close" or "timeOutNotClose"
 	OpenState string `json:"openState"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (ctx *ContactSensorEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+type BotEvent struct {
+	EventType    string          `json:"eventType"`
+	EventVersion string          `json:"eventVersion"`
+	Context      BotEventContext `json:"context"`
+}
+
+func (event BotEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event BotEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event BotEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event BotEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
+type BotEventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	// the current power state of the device, "on" or "off"
+	Power string `json:"power"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+	// the mode of the device, "pressMode", "switchMode", or "customizeMode"
+	DeviceMode BotDeviceMode `json:"deviceMode"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (ctx *BotEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+type BlindTiltEvent struct {
+	EventType    string                `json:"eventType"`
+	EventVersion string                `json:"eventVersion"`
+	Context      BlindTiltEventContext `json:"context"`
 }
 
+func (event BlindTiltEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event BlindTiltEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event BlindTiltEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event BlindTiltEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
+type BlindTiltEventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	// the opening direction of the device, "up" or "down"
+	Direction BlindTiltSetPositionDirection `json:"direction"`
+	// the percentage of the distance between the calibrated open position and closed position.
+	SlidePosition int `json:"slidePosition"`
+	// determines if the open and the closed positions have been properly calibrated or not
+	Calibrate bool `json:"calibrate"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (ctx *BlindTiltEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type MeterEvent struct {
 	EventType    string            `json:"eventType"`
 	EventVersion string            `json:"eventVersion"`
 	Context      MeterEventContext `json:"context"`
 }
 
+func (event MeterEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event MeterEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event MeterEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event MeterEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
 type MeterEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
 	TimeOfSample int64  `json:"timeOfSample"`
 
-	Temperature float64 `json:"temperature"`
-	Scale       string  `json:"scale"`
-	Humidity    int     `json:"humidity"`
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx MeterEventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
+}
+
+func (ctx *MeterEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type MeterPlusEvent struct {
 	EventType    string                `json:"eventType"`
 	EventVersion string                `json:"eventVersion"`
 	Context      MeterPlusEventContext `json:"context"`
 }
 
+func (event MeterPlusEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event MeterPlusEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event MeterPlusEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event MeterPlusEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
 type MeterPlusEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
 	TimeOfSample int64  `json:"timeOfSample"`
 
-	Temperature float64 `json:"temperature"`
-	Scale       string  `json:"scale"`
-	Humidity    int     `json:"humidity"`
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx MeterPlusEventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
+}
+
+func (ctx *MeterPlusEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+type OutdoorMeterEvent struct {
+	EventType    string                   `json:"eventType"`
+	EventVersion string                   `json:"eventVersion"`
+	Context      OutdoorMeterEventContext `json:"context"`
+}
+
+func (event OutdoorMeterEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event OutdoorMeterEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event OutdoorMeterEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event OutdoorMeterEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
+type OutdoorMeterEventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx OutdoorMeterEventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
+}
+
+func (ctx *OutdoorMeterEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+type MeterProEvent struct {
+	EventType    string               `json:"eventType"`
+	EventVersion string               `json:"eventVersion"`
+	Context      MeterProEventContext `json:"context"`
+}
+
+func (event MeterProEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event MeterProEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event MeterProEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event MeterProEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
+type MeterProEventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx MeterProEventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
+}
+
+func (ctx *MeterProEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+type MeterProCO2Event struct {
+	EventType    string                  `json:"eventType"`
+	EventVersion string                  `json:"eventVersion"`
+	Context      MeterProCO2EventContext `json:"context"`
+}
+
+func (event MeterProCO2Event) GetDeviceType() string  { return event.Context.DeviceType }
+func (event MeterProCO2Event) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event MeterProCO2Event) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event MeterProCO2Event) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
+type MeterProCO2EventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// CO2 is the CO2 concentration in ppm.
+	CO2 int `json:"CO2"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx MeterProCO2EventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
 }
 
+func (ctx *MeterProCO2EventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type LockEvent struct {
 	EventType    string           `json:"eventType"`
 	EventVersion string           `json:"eventVersion"`
 	Context      LockEventContext `json:"context"`
 }
 
+func (event LockEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event LockEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event LockEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event LockEvent) BatteryLow(int) bool    { return false }
+
 type LockEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -343,46 +780,74 @@ type LockEventContext struct {
 
 	// the state of the device, "LOCKED" stands for the motor is rotated to locking position;
 	// "UNLOCKED" stands for the motor is rotated to unlocking position; "JAMMED" stands for
-	// the motor is jammed while rotating
-	LockState string `json:"lockState"`
+	// the motor is jammed while rotating; "LATCHBOLTLOCKED" stands for only the latch bolt
+	// is locked, which is reported by Lock Pro devices
+	LockState LockState `json:"lockState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *LockEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type IndoorCamEvent struct {
 	EventType    string                `json:"eventType"`
 	EventVersion string                `json:"eventVersion"`
 	Context      IndoorCamEventContext `json:"context"`
 }
 
+func (event IndoorCamEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event IndoorCamEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event IndoorCamEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event IndoorCamEvent) BatteryLow(int) bool    { return false }
+
 type IndoorCamEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
 	TimeOfSample int64  `json:"timeOfSample"`
 
 	// the detection state of the device, "DETECTED" stands for motion is detected
-	DetectionState string `json:"detectionState"`
+	DetectionState DetectionState `json:"detectionState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *IndoorCamEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type PanTiltCamEvent struct {
 	EventType    string                 `json:"eventType"`
 	EventVersion string                 `json:"eventVersion"`
 	Context      PanTiltCamEventContext `json:"context"`
 }
 
+func (event PanTiltCamEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event PanTiltCamEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event PanTiltCamEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event PanTiltCamEvent) BatteryLow(int) bool    { return false }
+
 type PanTiltCamEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
 	TimeOfSample int64  `json:"timeOfSample"`
 
 	// the detection state of the device, "DETECTED" stands for motion is detected
-	DetectionState string `json:"detectionState"`
+	DetectionState DetectionState `json:"detectionState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *PanTiltCamEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type ColorBulbEvent struct {
 	EventType    string                `json:"eventType"`
 	EventVersion string                `json:"eventVersion"`
 	Context      ColorBulbEventContext `json:"context"`
 }
 
+func (event ColorBulbEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event ColorBulbEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event ColorBulbEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event ColorBulbEvent) BatteryLow(int) bool    { return false }
+
 type ColorBulbEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -396,14 +861,23 @@ type ColorBulbEventContext struct {
 	Color string `json:"color"`
 	// the color temperature value, range from 2700 to 6500
 	ColorTemperature int `json:"colorTemperature"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *ColorBulbEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type StripLightEvent struct {
 	EventType    string                 `json:"eventType"`
 	EventVersion string                 `json:"eventVersion"`
 	Context      StripLightEventContext `json:"context"`
 }
 
+func (event StripLightEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event StripLightEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event StripLightEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event StripLightEvent) BatteryLow(int) bool    { return false }
+
 type StripLightEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -415,14 +889,23 @@ type StripLightEventContext struct {
 	Brightness int `json:"brightness"`
 	// the color value, in the format of RGB value, "255:255:255"
 	Color string `json:"color"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *StripLightEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type PlugMiniJPEvent struct {
 	EventType    string                 `json:"eventType"`
 	EventVersion string                 `json:"eventVersion"`
 	Context      PlugMiniJPEventContext `json:"context"`
 }
 
+func (event PlugMiniJPEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event PlugMiniJPEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event PlugMiniJPEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event PlugMiniJPEvent) BatteryLow(int) bool    { return false }
+
 type PlugMiniJPEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -430,14 +913,23 @@ type PlugMiniJPEventContext struct {
 
 	// the current power state of the device, "ON" or "OFF"
 	PowerState PowerState `json:"powerState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *PlugMiniJPEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type PlugMiniUSEvent struct {
 	EventType    string                 `json:"eventType"`
 	EventVersion string                 `json:"eventVersion"`
 	Context      PlugMiniUSEventContext `json:"context"`
 }
 
+func (event PlugMiniUSEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event PlugMiniUSEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event PlugMiniUSEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event PlugMiniUSEvent) BatteryLow(int) bool    { return false }
+
 type PlugMiniUSEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -445,14 +937,25 @@ type PlugMiniUSEventContext struct {
 
 	// the current power state of the device, "ON" or "OFF"
 	PowerState PowerState `json:"powerState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *PlugMiniUSEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type SweeperEvent struct {
 	EventType    string              `json:"eventType"`
 	EventVersion string              `json:"eventVersion"`
 	Context      SweeperEventContext `json:"context"`
 }
 
+func (event SweeperEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event SweeperEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event SweeperEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event SweeperEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
 type SweeperEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -464,16 +967,27 @@ type SweeperEventContext struct {
 	WorkingStatus CleanerWorkingStatus `json:"workingStatus"`
 	// the connection status of the device, "online" or "offline"
 	OnlineStatus CleanerOnlineStatus `json:"onlineStatus"`
-	// the battery level.
-	Battery int `json:"battery"`
+	// the battery level, nil when the event does not have it.
+	// Note that this is a pointer to tell the absent battery level from 0%,
+	// while this was int, which is 0 for both of them, in the earlier releases.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *SweeperEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type CeilingEvent struct {
 	EventType    string              `json:"eventType"`
 	EventVersion string              `json:"eventVersion"`
 	Context      CeilingEventContext `json:"context"`
 }
 
+func (event CeilingEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event CeilingEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event CeilingEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event CeilingEvent) BatteryLow(int) bool    { return false }
+
 type CeilingEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -485,14 +999,23 @@ type CeilingEventContext struct {
 	Brightness int `json:"brightness"`
 	// the color temperature value, range from 2700 to 6500
 	ColorTemperature int `json:"colorTemperature"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
+func (ctx *CeilingEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
 type KeypadEvent struct {
 	EventType    string             `json:"eventType"`
 	EventVersion string             `json:"eventVersion"`
 	Context      KeypadEventContext `json:"context"`
 }
 
+func (event KeypadEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event KeypadEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event KeypadEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event KeypadEvent) BatteryLow(int) bool    { return false }
+
 type KeypadEventContext struct {
 	DeviceType   string `json:"deviceType"`
 	DeviceMac    string `json:"deviceMac"`
@@ -504,114 +1027,416 @@ type KeypadEventContext struct {
 	CommandID string `json:"commandId"`
 	// the result of the command, success, failed, or timeout
 	Result string `json:"result"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (ctx *KeypadEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+// DoorMode represents a mode of contact sensors reported when the enter or exit mode
+// gets triggered. Unknown values are kept as-is.
+type DoorMode string
+
+const (
+	// InDoor stands for someone entered through the door.
+	InDoor DoorMode = "IN_DOOR"
+	// OutDoor stands for someone went out through the door.
+	OutDoor DoorMode = "OUT_DOOR"
+)
+
+// DetectionState represents a detection state reported by webhook events of motion
+// sensors, contact sensors, cameras and Hub 3.
+type DetectionState string
+
+const (
+	// Detected stands for motion is detected.
+	Detected DetectionState = "DETECTED"
+	// NotDetected stands for motion has not been detected for some time.
+	NotDetected DetectionState = "NOT_DETECTED"
+)
+
+type Hub2Event struct {
+	EventType    string           `json:"eventType"`
+	EventVersion string           `json:"eventVersion"`
+	Context      Hub2EventContext `json:"context"`
+}
+
+func (event Hub2Event) GetDeviceType() string  { return event.Context.DeviceType }
+func (event Hub2Event) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event Hub2Event) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event Hub2Event) BatteryLow(int) bool    { return false }
+
+type Hub2EventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// the level of illuminance of the ambience light, 1~20
+	LightLevel int `json:"lightLevel"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx Hub2EventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
+}
+
+func (ctx *Hub2EventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+type Hub3Event struct {
+	EventType    string           `json:"eventType"`
+	EventVersion string           `json:"eventVersion"`
+	Context      Hub3EventContext `json:"context"`
+}
+
+func (event Hub3Event) GetDeviceType() string  { return event.Context.DeviceType }
+func (event Hub3Event) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event Hub3Event) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event Hub3Event) BatteryLow(int) bool    { return false }
+
+type Hub3EventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	Temperature float64          `json:"temperature"`
+	Scale       TemperatureScale `json:"scale"`
+	Humidity    int              `json:"humidity"`
+	// the level of illuminance of the ambience light, 1~20
+	LightLevel int `json:"lightLevel"`
+	// the motion state of the built-in presence sensor
+	DetectionState DetectionState `json:"detectionState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// TemperatureCelsius returns the temperature in Celsius, converting it when the scale is Fahrenheit.
+func (ctx Hub3EventContext) TemperatureCelsius() float64 {
+	return toCelsius(ctx.Temperature, ctx.Scale)
+}
+
+func (ctx *Hub3EventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+// VideoDoorbellEventName represents a kind of events reported by video doorbells.
+type VideoDoorbellEventName string
+
+const (
+	// VideoDoorbellRing stands for the doorbell button is pressed.
+	VideoDoorbellRing VideoDoorbellEventName = "ring"
+	// VideoDoorbellMotion stands for a motion is detected by the camera.
+	VideoDoorbellMotion VideoDoorbellEventName = "motion"
+)
+
+type VideoDoorbellEvent struct {
+	EventType    string                    `json:"eventType"`
+	EventVersion string                    `json:"eventVersion"`
+	Context      VideoDoorbellEventContext `json:"context"`
+}
+
+func (event VideoDoorbellEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event VideoDoorbellEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event VideoDoorbellEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event VideoDoorbellEvent) BatteryLow(int) bool    { return false }
+
+type VideoDoorbellEventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	// the kind of the event, "ring" or "motion"
+	EventName VideoDoorbellEventName `json:"eventName"`
+	// the detection state of the camera, "DETECTED" stands for motion is detected
+	DetectionState DetectionState `json:"detectionState"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (ctx *VideoDoorbellEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+// IsRing reports whether the event is caused by pressing the doorbell button.
+func (ctx VideoDoorbellEventContext) IsRing() bool {
+	return ctx.EventName == VideoDoorbellRing
+}
+
+// IsMotion reports whether the event is caused by a motion detected by the camera.
+// Events without eventName are treated as motion events when a motion is detected.
+func (ctx VideoDoorbellEventContext) IsMotion() bool {
+	return ctx.EventName == VideoDoorbellMotion || (ctx.EventName == "" && ctx.DetectionState == Detected)
 }
 
-func ParseWebhookRequest(r *http.Request) (interface{}, error) {
+// WaterLeakState represents a state of water leak detectors.
+type WaterLeakState string
+
+const (
+	WaterLeakNormal   WaterLeakState = "normal"
+	WaterLeakDetected WaterLeakState = "leak"
+)
+
+// UnmarshalJSON decodes a water leak state. The state is reported as either
+// 0/1 or "normal"/"leak" depending on the firmware, and both are accepted.
+// Unknown string values are kept as-is.
+func (state *WaterLeakState) UnmarshalJSON(b []byte) error {
+	var i int
+	if err := json.Unmarshal(b, &i); err != nil {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("cannot unmarshal to both of int and string: %w", err)
+		}
+
+		switch s {
+		case "0":
+			*state = WaterLeakNormal
+		case "1":
+			*state = WaterLeakDetected
+		default:
+			*state = WaterLeakState(s)
+		}
+
+		return nil
+	}
+
+	switch i {
+	case 0:
+		*state = WaterLeakNormal
+	case 1:
+		*state = WaterLeakDetected
+	default:
+		return fmt.Errorf("unknown water leak state: %d", i)
+	}
+
+	return nil
+}
+
+type WaterLeakEvent struct {
+	EventType    string                `json:"eventType"`
+	EventVersion string                `json:"eventVersion"`
+	Context      WaterLeakEventContext `json:"context"`
+}
+
+func (event WaterLeakEvent) GetDeviceType() string  { return event.Context.DeviceType }
+func (event WaterLeakEvent) GetDeviceMac() string   { return event.Context.DeviceMac }
+func (event WaterLeakEvent) GetTimeOfSample() int64 { return event.Context.TimeOfSample }
+func (event WaterLeakEvent) BatteryLow(threshold int) bool {
+	return batteryLow(event.Context.Battery, threshold)
+}
+
+type WaterLeakEventContext struct {
+	DeviceType   string `json:"deviceType"`
+	DeviceMac    string `json:"deviceMac"`
+	TimeOfSample int64  `json:"timeOfSample"`
+
+	// the leak state of the device, "normal" or "leak"
+	DetectionState WaterLeakState `json:"detectionState"`
+	// the battery level, nil when the event does not have it.
+	Battery *int `json:"battery,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+func (ctx *WaterLeakEventContext) setExtra(extra map[string]json.RawMessage) { ctx.Extra = extra }
+
+// ParseWebhookRequest parses a webhook request sent from SwitchBot and returns the event.
+// The returned event is a pointer to the concrete event type for the device type,
+// e.g. *MeterEvent, so you can get it by type assertion.
+// Any other values in the event context which are not mapped to the fields, e.g. objects
+// nested by newer firmware, are kept in the Extra field of the context.
+func ParseWebhookRequest(r *http.Request) (WebhookEvent, error) {
 	deviceType, err := deviceTypeFromWebhookRequest(r)
 	if err != nil {
 		return nil, err
 	}
 
 	switch deviceType {
+	case "WoHand":
+		// Bot
+		return decodeWebhookEvent(r.Body, func(event *BotEvent) extraSetter { return &event.Context })
 	case "WoPresence":
 		// Motion Sensor
-		var event MotionSensorEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *MotionSensorEvent) extraSetter { return &event.Context })
 	case "WoContact":
 		// Contact Sensor
-		var event ContactSensorEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
-	case "WoLock":
-		// Lock
-		var event LockEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *ContactSensorEvent) extraSetter { return &event.Context })
+	case "WoLock", "WoLockPro":
+		// Lock, Lock Pro
+		return decodeWebhookEvent(r.Body, func(event *LockEvent) extraSetter { return &event.Context })
 	case "WoCamera":
 		// Indoor Cam
-		var event IndoorCamEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *IndoorCamEvent) extraSetter { return &event.Context })
 	case "WoPanTiltCam":
 		// Pan/Tilt Cam
-		var event PanTiltCamEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *PanTiltCamEvent) extraSetter { return &event.Context })
 	case "WoBulb":
 		// Color Bulb
-		var event ColorBulbEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *ColorBulbEvent) extraSetter { return &event.Context })
 	case "WoStrip":
 		// LED Strip Light
-		var event StripLightEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *StripLightEvent) extraSetter { return &event.Context })
 	case "WoPlugUS":
 		// Plug Mini (US)
-		var event PlugMiniUSEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *PlugMiniUSEvent) extraSetter { return &event.Context })
 	case "WoPlugJP":
 		// Plug Mini (JP)
-		var event PlugMiniJPEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *PlugMiniJPEvent) extraSetter { return &event.Context })
 	case "WoMeter":
 		// Meter
-		var event MeterEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *MeterEvent) extraSetter { return &event.Context })
 	case "WoMeterPlus":
 		// Meter Plus
-		var event MeterPlusEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *MeterPlusEvent) extraSetter { return &event.Context })
+	case "WoIOSensor":
+		// Indoor/Outdoor Thermo-Hygrometer
+		return decodeWebhookEvent(r.Body, func(event *OutdoorMeterEvent) extraSetter { return &event.Context })
+	case "WoMeterPro":
+		// Meter Pro
+		return decodeWebhookEvent(r.Body, func(event *MeterProEvent) extraSetter { return &event.Context })
+	case "WoMeterProCO2":
+		// Meter Pro (CO2 Monitor)
+		return decodeWebhookEvent(r.Body, func(event *MeterProCO2Event) extraSetter { return &event.Context })
+	case "WoHub2":
+		// Hub 2
+		return decodeWebhookEvent(r.Body, func(event *Hub2Event) extraSetter { return &event.Context })
+	case "WoHub3":
+		// Hub 3
+		return decodeWebhookEvent(r.Body, func(event *Hub3Event) extraSetter { return &event.Context })
+	case "WoVideoDoorbell":
+		// Video Doorbell
+		return decodeWebhookEvent(r.Body, func(event *VideoDoorbellEvent) extraSetter { return &event.Context })
+	case "WoWaterDetector":
+		// Water Leak Detector
+		return decodeWebhookEvent(r.Body, func(event *WaterLeakEvent) extraSetter { return &event.Context })
+	case "WoBlindTilt":
+		// Blind Tilt
+		return decodeWebhookEvent(r.Body, func(event *BlindTiltEvent) extraSetter { return &event.Context })
 	case "WoSweeper", "WoSweeperPlus":
 		// Cleaner
-		var event SweeperEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *SweeperEvent) extraSetter { return &event.Context })
 	case "WoCeiling", "WoCeilingPro":
 		// Ceiling lights
-		var event CeilingEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *CeilingEvent) extraSetter { return &event.Context })
 	case "WoKeypad", "WoKeypadTouch":
 		// keypad
-		var event KeypadEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, err
-		}
-		return &event, nil
+		return decodeWebhookEvent(r.Body, func(event *KeypadEvent) extraSetter { return &event.Context })
 	default:
 		return nil, fmt.Errorf("unknown device type: %s", deviceType)
 	}
 }
+
+// extraSetter is implemented by the webhook event contexts to keep the values which
+// are not mapped to any field of the context.
+type extraSetter interface {
+	setExtra(extra map[string]json.RawMessage)
+}
+
+// decodeWebhookEvent decodes a webhook event of type E from r. The values in the
+// event context which are not mapped to any field are set to the context returned
+// by contextOf.
+func decodeWebhookEvent[E any, P interface {
+	*E
+	WebhookEvent
+}](r io.Reader, contextOf func(P) extraSetter) (WebhookEvent, error) {
+	var raw struct {
+		Context json.RawMessage `json:"context"`
+	}
+	var b json.RawMessage
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	event := P(new(E))
+	if err := json.Unmarshal(b, event); err != nil {
+		return nil, err
+	}
+
+	if len(raw.Context) > 0 && string(raw.Context) != "null" {
+		ctx := contextOf(event)
+		extra, err := unmarshalWithExtra(raw.Context, ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx.setExtra(extra)
+	}
+
+	return event, nil
+}
+
+// ParseWebhookRequestAs parses a webhook request as same as ParseWebhookRequest, but returns
+// the event as given type T, e.g. ParseWebhookRequestAs[MeterEvent](r).
+// An error is returned when the device type of the request does not map to T.
+func ParseWebhookRequestAs[T any](r *http.Request) (*T, error) {
+	deviceType, err := deviceTypeFromWebhookRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := ParseWebhookRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	typed, ok := interface{}(event).(*T)
+	if !ok {
+		return nil, fmt.Errorf("webhook event for device type %s is %T, not %T", deviceType, event, typed)
+	}
+
+	return typed, nil
+}
+
+// ParseRequest parses a webhook request as same as ParseWebhookRequest. In addition,
+// the keys in the event context which are not mapped to any field are logged when
+// the client is configured with WithSchemaDriftLogging.
+func (svc *WebhookService) ParseRequest(r *http.Request) (WebhookEvent, error) {
+	event, err := ParseWebhookRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.c.logUnmappedKeys(r.Context(), "webhook event", event.GetDeviceType(), webhookEventExtra(event))
+
+	return event, nil
+}
+
+// webhookEventExtra returns the Extra field of the context of given event, if any.
+func webhookEventExtra(event WebhookEvent) map[string]json.RawMessage {
+	v := reflect.Indirect(reflect.ValueOf(event))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ctx := v.FieldByName("Context")
+	if ctx.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field := ctx.FieldByName("Extra")
+	if !field.IsValid() {
+		return nil
+	}
+
+	extra, _ := field.Interface().(map[string]json.RawMessage)
+	return extra
+}
+
+// NewWebhookTestRequest returns a new request shaped as same as webhook requests sent
+// from SwitchBot for given event, which can be parsed by ParseWebhookRequest.
+// This is useful to test your webhook handlers locally.
+func NewWebhookTestRequest(event WebhookEvent) (*http.Request, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(event); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/", &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
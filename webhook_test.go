@@ -4,53 +4,106 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/nasa9084/go-switchbot/v4"
 )
 
 func TestWebhookSetup(t *testing.T) {
-	srv := httptest.NewServer(
-		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(`{"statusCode":100,"body":{},"message":""}`))
+	tests := []struct {
+		label          string
+		deviceList     []string
+		wantDeviceList string
+	}{
+		{
+			label:          "ALL",
+			deviceList:     []string{"ALL"},
+			wantDeviceList: "ALL",
+		},
+		{
+			label:          "default",
+			wantDeviceList: "ALL",
+		},
+		{
+			label:          "device IDs",
+			deviceList:     []string{"C271111EC0AB", "E2F6032048AB"},
+			wantDeviceList: "C271111EC0AB,E2F6032048AB",
+		},
+	}
 
-			if r.Method != http.MethodPost {
-				t.Fatalf("POST method is expected but %s", r.Method)
-			}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"statusCode":100,"body":{},"message":""}`))
 
-			var got map[string]string
-			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					if r.Method != http.MethodPost {
+						t.Fatalf("POST method is expected but %s", r.Method)
+					}
+
+					var got map[string]string
+					if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+						t.Fatal(err)
+					}
+
+					want := map[string]string{
+						"action":     "setupWebhook",
+						"url":        "url1",
+						"deviceList": tt.wantDeviceList,
+					}
+
+					if diff := cmp.Diff(want, got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+			if err := c.Webhook().Setup(context.Background(), "url1", tt.deviceList...); err != nil {
 				t.Fatal(err)
 			}
+		})
+	}
 
-			want := map[string]string{
-				"action":     "setupWebhook",
-				"url":        "url1",
-				"deviceList": "ALL",
-			}
+	t.Run("error status code", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"statusCode":190,"body":{},"message":"url is invalid"}`))
+			}),
+		)
+		defer srv.Close()
 
-			if diff := cmp.Diff(want, got); diff != "" {
-				t.Fatalf("event mismatch (-want +got):\n%s", diff)
-			}
-		}),
-	)
-	defer srv.Close()
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+		if err := c.Webhook().Setup(context.Background(), "url1"); err == nil {
+			t.Fatal("an error should be returned for statusCode 190")
+		}
+	})
 
-	if err := c.Webhook().Setup(context.Background(), "url1", "ALL"); err != nil {
-		t.Fatal(err)
-	}
+	t.Run("empty URL", func(t *testing.T) {
+		c := switchbot.New("", "")
+
+		if err := c.Webhook().Setup(context.Background(), ""); err == nil {
+			t.Fatal("an error should be returned when URL is empty")
+		}
+	})
 }
 
 func TestWebhookQuery(t *testing.T) {
 	t.Run("queryUrl", func(t *testing.T) {
 		srv := httptest.NewServer(
 			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.Write([]byte(`{"statusCode":100,"body":{"urls":[url1]},"message":""}`))
+				w.Write([]byte(`{"statusCode":100,"body":{"urls":["url1"]},"message":""}`))
 
 				if r.Method != http.MethodPost {
 					t.Fatalf("POST method is expected but %s", r.Method)
@@ -75,15 +128,24 @@ func TestWebhookQuery(t *testing.T) {
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		if err := c.Webhook().Query(context.Background(), switchbot.QueryURL, ""); err != nil {
+		got, err := c.Webhook().Query(context.Background(), switchbot.QueryURL, "")
+		if err != nil {
 			t.Fatal(err)
 		}
+
+		want := &switchbot.WebhookQueryResult{
+			URLs: []string{"url1"},
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("query result mismatch (-want +got):\n%s", diff)
+		}
 	})
 
 	t.Run("queryDetails", func(t *testing.T) {
 		srv := httptest.NewServer(
 			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.Write([]byte(`{"statusCode":100,"body":[{"url":url1,"createTime":123456,"lastUpdateTime":123456,"deviceList":"ALL","enable":true}],"message":""}`))
+				w.Write([]byte(`{"statusCode":100,"body":[{"url":"url1","createTime":123456,"lastUpdateTime":123456,"deviceList":"ALL","enable":true}],"message":""}`))
 
 				if r.Method != http.MethodPost {
 					t.Fatalf("POST method is expected but %s", r.Method)
@@ -108,12 +170,113 @@ func TestWebhookQuery(t *testing.T) {
 
 		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
 
-		if err := c.Webhook().Query(context.Background(), switchbot.QueryDetails, "url1"); err != nil {
+		got, err := c.Webhook().Query(context.Background(), switchbot.QueryDetails, "url1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &switchbot.WebhookQueryResult{
+			Details: []switchbot.WebhookQueryDetails{
+				{
+					URL:        "url1",
+					CreateTime: 123456,
+					LastUpdate: 123456,
+					DeviceList: "ALL",
+					Enable:     true,
+				},
+			},
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("query result mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestWebhookQueryUrls(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+		want  []string
+	}{
+		{
+			label: "multiple urls",
+			body:  `{"statusCode":100,"body":{"urls":["url1","url2"]},"message":""}`,
+			want:  []string{"url1", "url2"},
+		},
+		{
+			label: "no url",
+			body:  `{"statusCode":100,"body":{"urls":[]},"message":""}`,
+			want:  []string{},
+		},
+		{
+			label: "urls not reported",
+			body:  `{"statusCode":100,"body":{},"message":""}`,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			srv := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(tt.body))
+				}),
+			)
+			defer srv.Close()
+
+			c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+			got, err := c.Webhook().QueryUrls(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got == nil {
+				t.Fatal("an empty slice is expected but nil")
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("urls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("first url by QueryUrl", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"statusCode":100,"body":{"urls":["url1","url2"]},"message":""}`))
+			}),
+		)
+		defer srv.Close()
+
+		c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+		got, err := c.Webhook().QueryUrl(context.Background())
+		if err != nil {
 			t.Fatal(err)
 		}
+
+		if got != "url1" {
+			t.Errorf("unexpected url: %s != url1", got)
+		}
 	})
 }
 
+func TestWebhookQueryError(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"statusCode":190,"body":{},"message":"error"}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := switchbot.New("", "", switchbot.WithEndpoint(srv.URL))
+
+	if _, err := c.Webhook().Query(context.Background(), switchbot.QueryURL, ""); err == nil {
+		t.Fatal("an error should be returned for statusCode 190")
+	}
+}
+
 func TestWebhookUpdate(t *testing.T) {
 	srv := httptest.NewServer(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -203,7 +366,7 @@ func TestParseWebhook(t *testing.T) {
 						Context: switchbot.MotionSensorEventContext{
 							DeviceType:     "WoPresence",
 							DeviceMac:      "01:00:5e:90:10:00",
-							DetectionState: "NOT_DETECTED",
+							DetectionState: switchbot.NotDetected,
 							TimeOfSample:   123456789,
 						},
 					}
@@ -236,8 +399,8 @@ func TestParseWebhook(t *testing.T) {
 						Context: switchbot.ContactSensorEventContext{
 							DeviceType:     "WoContact",
 							DeviceMac:      "01:00:5e:90:10:00",
-							DetectionState: "NOT_DETECTED",
-							DoorMode:       "OUT_DOOR",
+							DetectionState: switchbot.NotDetected,
+							DoorMode:       switchbot.OutDoor,
 							Brightness:     switchbot.AmbientBrightnessDim,
 							OpenState:      "open",
 							TimeOfSample:   123456789,
@@ -329,6 +492,45 @@ func TestParseWebhook(t *testing.T) {
 		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterPlus","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789}}`)
 	})
 
+	t.Run("outdoor meter", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.OutdoorMeterEvent); ok {
+					want := switchbot.OutdoorMeterEvent{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.OutdoorMeterEventContext{
+							DeviceType:   "WoIOSensor",
+							DeviceMac:    "01:00:5e:90:10:00",
+							Temperature:  77,
+							Scale:        switchbot.Fahrenheit,
+							Humidity:     31,
+							TimeOfSample: 123456789,
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+
+					if celsius := got.Context.TemperatureCelsius(); celsius != 25 {
+						t.Errorf("unexpected temperature in celsius: %f != 25", celsius)
+					}
+				} else {
+					t.Fatalf("given webhook event must be an outdoor meter event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoIOSensor","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"timeOfSample":123456789}}`)
+	})
+
 	t.Run("lock", func(t *testing.T) {
 		srv := httptest.NewServer(
 			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -344,7 +546,7 @@ func TestParseWebhook(t *testing.T) {
 						Context: switchbot.LockEventContext{
 							DeviceType:   "WoLock",
 							DeviceMac:    "01:00:5e:90:10:00",
-							LockState:    "LOCKED",
+							LockState:    switchbot.Locked,
 							TimeOfSample: 123456789,
 						},
 					}
@@ -362,6 +564,43 @@ func TestParseWebhook(t *testing.T) {
 		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoLock","deviceMac":"01:00:5e:90:10:00","lockState":"LOCKED","timeOfSample":123456789}}`)
 	})
 
+	t.Run("lock with battery", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.LockEvent); ok {
+					want := switchbot.LockEvent{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.LockEventContext{
+							DeviceType:   "WoLock",
+							DeviceMac:    "01:00:5e:90:10:00",
+							LockState:    switchbot.Locked,
+							TimeOfSample: 123456789,
+							// the battery level is not documented for lock events
+							Extra: map[string]json.RawMessage{
+								"battery": json.RawMessage(`95`),
+							},
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a lock event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoLock","deviceMac":"01:00:5e:90:10:00","lockState":"LOCKED","battery":95,"timeOfSample":123456789}}`)
+	})
+
 	t.Run("indoor cam", func(t *testing.T) {
 		srv := httptest.NewServer(
 			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -377,7 +616,7 @@ func TestParseWebhook(t *testing.T) {
 						Context: switchbot.IndoorCamEventContext{
 							DeviceType:     "WoCamera",
 							DeviceMac:      "01:00:5e:90:10:00",
-							DetectionState: "DETECTED",
+							DetectionState: switchbot.Detected,
 							TimeOfSample:   123456789,
 						},
 					}
@@ -410,7 +649,7 @@ func TestParseWebhook(t *testing.T) {
 						Context: switchbot.PanTiltCamEventContext{
 							DeviceType:     "WoPanTiltCam",
 							DeviceMac:      "01:00:5e:90:10:00",
-							DetectionState: "DETECTED",
+							DetectionState: switchbot.Detected,
 							TimeOfSample:   123456789,
 						},
 					}
@@ -582,7 +821,7 @@ func TestParseWebhook(t *testing.T) {
 							DeviceMac:     "01:00:5e:90:10:00",
 							WorkingStatus: switchbot.CleanerStandBy,
 							OnlineStatus:  switchbot.CleanerOnline,
-							Battery:       100,
+							Battery:       intPtr(100),
 							TimeOfSample:  123456789,
 						},
 					}
@@ -617,7 +856,7 @@ func TestParseWebhook(t *testing.T) {
 							DeviceMac:     "01:00:5e:90:10:00",
 							WorkingStatus: switchbot.CleanerStandBy,
 							OnlineStatus:  switchbot.CleanerOnline,
-							Battery:       100,
+							Battery:       intPtr(100),
 							TimeOfSample:  123456789,
 						},
 					}
@@ -846,4 +1085,890 @@ func TestParseWebhook(t *testing.T) {
 			sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoKeypadTouch","deviceMac":"01:00:5e:90:10:00","eventName":"deleteKey","commandId":"CMD-1663558451952-01","result":"success","timeOfSample":123456789}}`)
 		})
 	})
-}
+
+	t.Run("meter with nested context values", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.MeterEvent); ok {
+					want := switchbot.MeterEvent{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.MeterEventContext{
+							DeviceType:   "WoMeter",
+							DeviceMac:    "01:00:5e:90:10:00",
+							Temperature:  22.5,
+							Scale:        "CELSIUS",
+							Humidity:     31,
+							TimeOfSample: 123456789,
+							Extra: map[string]json.RawMessage{
+								"sensor": json.RawMessage(`{"probe":{"temperature":18.2}}`),
+							},
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a meter event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789,"sensor":{"probe":{"temperature":18.2}}}}`)
+	})
+
+	t.Run("hub 2", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.Hub2Event); ok {
+					want := switchbot.Hub2Event{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.Hub2EventContext{
+							DeviceType:   "WoHub2",
+							DeviceMac:    "01:00:5e:90:10:00",
+							Temperature:  13.3,
+							Scale:        "CELSIUS",
+							Humidity:     18,
+							LightLevel:   5,
+							TimeOfSample: 123456789,
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a hub 2 event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoHub2","deviceMac":"01:00:5e:90:10:00","temperature":13.3,"scale":"CELSIUS","humidity":18,"lightLevel":5,"timeOfSample":123456789}}`)
+	})
+
+	t.Run("hub 3", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.Hub3Event); ok {
+					want := switchbot.Hub3Event{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.Hub3EventContext{
+							DeviceType:     "WoHub3",
+							DeviceMac:      "01:00:5e:90:10:00",
+							Temperature:    13.3,
+							Scale:          "CELSIUS",
+							Humidity:       18,
+							LightLevel:     5,
+							DetectionState: switchbot.Detected,
+							TimeOfSample:   123456789,
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a hub 3 event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoHub3","deviceMac":"01:00:5e:90:10:00","temperature":13.3,"scale":"CELSIUS","humidity":18,"lightLevel":5,"detectionState":"DETECTED","timeOfSample":123456789}}`)
+	})
+
+	t.Run("water leak detector", func(t *testing.T) {
+		tests := []struct {
+			label string
+			body  string
+			want  switchbot.WaterLeakState
+		}{
+			{
+				label: "numeric",
+				body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoWaterDetector","deviceMac":"01:00:5e:90:10:00","detectionState":1,"battery":90,"timeOfSample":123456789}}`,
+				want:  switchbot.WaterLeakDetected,
+			},
+			{
+				label: "string",
+				body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoWaterDetector","deviceMac":"01:00:5e:90:10:00","detectionState":"normal","battery":90,"timeOfSample":123456789}}`,
+				want:  switchbot.WaterLeakNormal,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						event, err := switchbot.ParseWebhookRequest(r)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						if got, ok := event.(*switchbot.WaterLeakEvent); ok {
+							want := switchbot.WaterLeakEvent{
+								EventType:    "changeReport",
+								EventVersion: "1",
+								Context: switchbot.WaterLeakEventContext{
+									DeviceType:     "WoWaterDetector",
+									DeviceMac:      "01:00:5e:90:10:00",
+									DetectionState: tt.want,
+									Battery:        intPtr(90),
+									TimeOfSample:   123456789,
+								},
+							}
+
+							if diff := cmp.Diff(want, *got); diff != "" {
+								t.Fatalf("event mismatch (-want +got):\n%s", diff)
+							}
+						} else {
+							t.Fatalf("given webhook event must be a water leak event but %T", event)
+						}
+					}),
+				)
+				defer srv.Close()
+
+				sendWebhook(srv.URL, tt.body)
+			})
+		}
+	})
+
+	t.Run("video doorbell", func(t *testing.T) {
+		tests := []struct {
+			label      string
+			body       string
+			want       switchbot.VideoDoorbellEventContext
+			wantRing   bool
+			wantMotion bool
+		}{
+			{
+				label: "ring",
+				body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoVideoDoorbell","deviceMac":"01:00:5e:90:10:00","eventName":"ring","timeOfSample":123456789}}`,
+				want: switchbot.VideoDoorbellEventContext{
+					DeviceType:   "WoVideoDoorbell",
+					DeviceMac:    "01:00:5e:90:10:00",
+					EventName:    switchbot.VideoDoorbellRing,
+					TimeOfSample: 123456789,
+				},
+				wantRing: true,
+			},
+			{
+				label: "motion",
+				body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoVideoDoorbell","deviceMac":"01:00:5e:90:10:00","eventName":"motion","detectionState":"DETECTED","timeOfSample":123456789}}`,
+				want: switchbot.VideoDoorbellEventContext{
+					DeviceType:     "WoVideoDoorbell",
+					DeviceMac:      "01:00:5e:90:10:00",
+					EventName:      switchbot.VideoDoorbellMotion,
+					DetectionState: switchbot.Detected,
+					TimeOfSample:   123456789,
+				},
+				wantMotion: true,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.label, func(t *testing.T) {
+				srv := httptest.NewServer(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						event, err := switchbot.ParseWebhookRequest(r)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						if got, ok := event.(*switchbot.VideoDoorbellEvent); ok {
+							want := switchbot.VideoDoorbellEvent{
+								EventType:    "changeReport",
+								EventVersion: "1",
+								Context:      tt.want,
+							}
+
+							if diff := cmp.Diff(want, *got); diff != "" {
+								t.Fatalf("event mismatch (-want +got):\n%s", diff)
+							}
+
+							if got.Context.IsRing() != tt.wantRing {
+								t.Errorf("IsRing() should be %t", tt.wantRing)
+							}
+							if got.Context.IsMotion() != tt.wantMotion {
+								t.Errorf("IsMotion() should be %t", tt.wantMotion)
+							}
+						} else {
+							t.Fatalf("given webhook event must be a video doorbell event but %T", event)
+						}
+					}),
+				)
+				defer srv.Close()
+
+				sendWebhook(srv.URL, tt.body)
+			})
+		}
+	})
+
+	t.Run("bot", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.BotEvent); ok {
+					want := switchbot.BotEvent{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.BotEventContext{
+							DeviceType:   "WoHand",
+							DeviceMac:    "01:00:5e:90:10:00",
+							Power:        "on",
+							Battery:      intPtr(10),
+							DeviceMode:   switchbot.BotSwitchMode,
+							TimeOfSample: 123456789,
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a bot event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoHand","deviceMac":"01:00:5e:90:10:00","power":"on","battery":10,"deviceMode":"switchMode","timeOfSample":123456789}}`)
+	})
+
+	t.Run("blind tilt", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.BlindTiltEvent); ok {
+					want := switchbot.BlindTiltEvent{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.BlindTiltEventContext{
+							DeviceType:    "WoBlindTilt",
+							DeviceMac:     "01:00:5e:90:10:00",
+							Direction:     switchbot.UpDirection,
+							SlidePosition: 50,
+							Calibrate:     true,
+							Battery:       intPtr(100),
+							TimeOfSample:  123456789,
+							Extra: map[string]json.RawMessage{
+								"version": json.RawMessage(`"V1.0"`),
+								"group":   json.RawMessage(`false`),
+							},
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a blind tilt event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoBlindTilt","deviceMac":"01:00:5e:90:10:00","version":"V1.0","calibrate":true,"group":false,"direction":"up","slidePosition":50,"battery":100,"timeOfSample":123456789}}`)
+	})
+
+	t.Run("meter pro", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.MeterProEvent); ok {
+					want := switchbot.MeterProEvent{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.MeterProEventContext{
+							DeviceType:   "WoMeterPro",
+							DeviceMac:    "01:00:5e:90:10:00",
+							Temperature:  22.5,
+							Scale:        switchbot.Celsius,
+							Humidity:     31,
+							TimeOfSample: 123456789,
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a meter pro event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterPro","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789}}`)
+	})
+
+	t.Run("meter pro co2", func(t *testing.T) {
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := switchbot.ParseWebhookRequest(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got, ok := event.(*switchbot.MeterProCO2Event); ok {
+					want := switchbot.MeterProCO2Event{
+						EventType:    "changeReport",
+						EventVersion: "1",
+						Context: switchbot.MeterProCO2EventContext{
+							DeviceType:   "WoMeterProCO2",
+							DeviceMac:    "01:00:5e:90:10:00",
+							Temperature:  22.5,
+							Scale:        switchbot.Celsius,
+							Humidity:     31,
+							CO2:          812,
+							TimeOfSample: 123456789,
+						},
+					}
+
+					if diff := cmp.Diff(want, *got); diff != "" {
+						t.Fatalf("event mismatch (-want +got):\n%s", diff)
+					}
+				} else {
+					t.Fatalf("given webhook event must be a meter pro co2 event but %T", event)
+				}
+			}),
+		)
+		defer srv.Close()
+
+		sendWebhook(srv.URL, `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterProCO2","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"CO2":812,"timeOfSample":123456789}}`)
+	})
+}
+
+func TestWebhookHandler(t *testing.T) {
+	t.Run("meter", func(t *testing.T) {
+		var got switchbot.WebhookEvent
+		srv := httptest.NewServer(switchbot.WebhookHandler(func(event switchbot.WebhookEvent) error {
+			got = event
+			return nil
+		}))
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewBufferString(`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != `{"statusCode":100}` {
+			t.Errorf("unexpected response body: %s", body)
+		}
+
+		want := &switchbot.MeterEvent{
+			EventType:    "changeReport",
+			EventVersion: "1",
+			Context: switchbot.MeterEventContext{
+				DeviceType:   "WoMeter",
+				DeviceMac:    "01:00:5e:90:10:00",
+				Temperature:  22.5,
+				Scale:        switchbot.Celsius,
+				Humidity:     31,
+				TimeOfSample: 123456789,
+			},
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("event mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("malformed request", func(t *testing.T) {
+		srv := httptest.NewServer(switchbot.WebhookHandler(func(event switchbot.WebhookEvent) error {
+			t.Errorf("callback should not be called for malformed request but called with %#v", event)
+			return nil
+		}))
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewBufferString(`{"eventType":`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestDebounceWebhook(t *testing.T) {
+	var called int
+	handler := switchbot.DebounceWebhook(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called++
+
+			if _, err := switchbot.ParseWebhookRequest(r); err != nil {
+				t.Error(err)
+			}
+		}),
+		time.Hour,
+	)
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewBufferString(`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoPresence","deviceMac":"01:00:5e:90:10:00","detectionState":"DETECTED","timeOfSample":123456789}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+	}
+
+	if called != 1 {
+		t.Errorf("rapid events from the same device should be coalesced into one but handler is called %d times", called)
+	}
+
+	resp, err := http.Post(srv.URL, "application/json", bytes.NewBufferString(`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoPresence","deviceMac":"01:00:5e:90:10:01","detectionState":"DETECTED","timeOfSample":123456789}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if called != 2 {
+		t.Errorf("events from another device should not be coalesced but handler is called %d times", called)
+	}
+}
+
+func TestDebounceWebhookStateChange(t *testing.T) {
+	var delivered []switchbot.OpenState
+	handler := switchbot.DebounceWebhook(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event, err := switchbot.ParseWebhookRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			delivered = append(delivered, switchbot.OpenState(event.(*switchbot.ContactSensorEvent).Context.OpenState))
+		}),
+		time.Hour,
+	)
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	for _, body := range []string{
+		`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoContact","deviceMac":"01:00:5e:90:10:00","detectionState":"DETECTED","doorMode":"OUT_DOOR","brightness":"dim","openState":"open","timeOfSample":123456789}}`,
+		// a duplicate with another time of sample is dropped
+		`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoContact","deviceMac":"01:00:5e:90:10:00","detectionState":"DETECTED","doorMode":"OUT_DOOR","brightness":"dim","openState":"open","timeOfSample":123456790}}`,
+		`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoContact","deviceMac":"01:00:5e:90:10:00","detectionState":"DETECTED","doorMode":"OUT_DOOR","brightness":"dim","openState":"close","timeOfSample":123456791}}`,
+	} {
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+	}
+
+	want := []switchbot.OpenState{switchbot.ContactOpen, switchbot.ContactClose}
+	if diff := cmp.Diff(want, delivered); diff != "" {
+		t.Errorf("delivered states mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseWebhookRequestAs(t *testing.T) {
+	const meterWebhook = `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789}}`
+
+	t.Run("matched type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(meterWebhook))
+
+		got, err := switchbot.ParseWebhookRequestAs[switchbot.MeterEvent](r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &switchbot.MeterEvent{
+			EventType:    "changeReport",
+			EventVersion: "1",
+			Context: switchbot.MeterEventContext{
+				DeviceType:   "WoMeter",
+				DeviceMac:    "01:00:5e:90:10:00",
+				Temperature:  22.5,
+				Scale:        "CELSIUS",
+				Humidity:     31,
+				TimeOfSample: 123456789,
+			},
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("event mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("mismatched type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(meterWebhook))
+
+		if _, err := switchbot.ParseWebhookRequestAs[switchbot.LockEvent](r); err == nil {
+			t.Fatal("an error should be returned when the device type does not map to the given type")
+		}
+	})
+}
+
+func TestParseWebhookEmptyBody(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+	}{
+		{label: "empty", body: ``},
+		{label: "truncated", body: `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMe`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+
+			if _, err := switchbot.ParseWebhookRequest(r); !errors.Is(err, switchbot.ErrEmptyWebhookBody) {
+				t.Fatalf("ErrEmptyWebhookBody is expected but %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookEvent(t *testing.T) {
+	tests := []struct {
+		label          string
+		body           string
+		wantDeviceType string
+	}{
+		{
+			label:          "motion sensor",
+			body:           `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoPresence","deviceMac":"01:00:5e:90:10:00","detectionState":"NOT_DETECTED","timeOfSample":123456789}}`,
+			wantDeviceType: "WoPresence",
+		},
+		{
+			label:          "meter",
+			body:           `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789}}`,
+			wantDeviceType: "WoMeter",
+		},
+		{
+			label:          "lock",
+			body:           `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoLock","deviceMac":"01:00:5e:90:10:00","lockState":"LOCKED","timeOfSample":123456789}}`,
+			wantDeviceType: "WoLock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+
+			event, err := switchbot.ParseWebhookRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := event.GetDeviceType(); got != tt.wantDeviceType {
+				t.Errorf("unexpected device type: %s != %s", got, tt.wantDeviceType)
+			}
+			if got := event.GetDeviceMac(); got != "01:00:5e:90:10:00" {
+				t.Errorf("unexpected device mac: %s", got)
+			}
+			if got := event.GetTimeOfSample(); got != 123456789 {
+				t.Errorf("unexpected time of sample: %d", got)
+			}
+		})
+	}
+}
+
+func TestWebhookEventBatteryLow(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+		want  bool
+	}{
+		{
+			label: "bot with low battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoHand","deviceMac":"01:00:5e:90:10:00","power":"on","battery":10,"deviceMode":"pressMode","timeOfSample":123456789}}`,
+			want:  true,
+		},
+		{
+			label: "blind tilt with enough battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoBlindTilt","deviceMac":"01:00:5e:90:10:00","direction":"up","slidePosition":50,"battery":100,"timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "water detector at threshold",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoWaterDetector","deviceMac":"01:00:5e:90:10:00","detectionState":0,"battery":20,"timeOfSample":123456789}}`,
+			want:  true,
+		},
+		{
+			label: "lock without battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoLock","deviceMac":"01:00:5e:90:10:00","lockState":"LOCKED","timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "lock pro with undocumented battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoLockPro","deviceMac":"01:00:5e:90:10:00","lockState":"LATCHBOLTLOCKED","battery":5,"timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "bot without battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoHand","deviceMac":"01:00:5e:90:10:00","power":"on","deviceMode":"pressMode","timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "water detector without battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoWaterDetector","deviceMac":"01:00:5e:90:10:00","detectionState":0,"timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "motion sensor with low battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoPresence","deviceMac":"01:00:5e:90:10:00","detectionState":"DETECTED","battery":15,"timeOfSample":123456789}}`,
+			want:  true,
+		},
+		{
+			label: "contact sensor with enough battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoContact","deviceMac":"01:00:5e:90:10:00","detectionState":"NOT_DETECTED","doorMode":"OUT_DOOR","brightness":"dim","openState":"open","battery":90,"timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "meter with low battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"battery":10,"timeOfSample":123456789}}`,
+			want:  true,
+		},
+		{
+			label: "meter plus with low battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterPlus","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"battery":20,"timeOfSample":123456789}}`,
+			want:  true,
+		},
+		{
+			label: "meter pro with enough battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterPro","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"battery":21,"timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "meter pro CO2 with low battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterProCO2","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"CO2":800,"battery":5,"timeOfSample":123456789}}`,
+			want:  true,
+		},
+		{
+			label: "motion sensor without battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoPresence","deviceMac":"01:00:5e:90:10:00","detectionState":"NOT_DETECTED","timeOfSample":123456789}}`,
+			want:  false,
+		},
+		{
+			label: "meter without battery",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":22.5,"scale":"CELSIUS","humidity":31,"timeOfSample":123456789}}`,
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+
+			event, err := switchbot.ParseWebhookRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := event.BatteryLow(20); got != tt.want {
+				t.Errorf("unexpected battery low: %t != %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeterEventContextTemperatureCelsius(t *testing.T) {
+	tests := []struct {
+		label string
+		ctx   switchbot.MeterEventContext
+		want  float64
+	}{
+		{
+			label: "celsius",
+			ctx:   switchbot.MeterEventContext{Temperature: 22.5, Scale: switchbot.Celsius},
+			want:  22.5,
+		},
+		{
+			label: "fahrenheit",
+			ctx:   switchbot.MeterEventContext{Temperature: 77, Scale: switchbot.Fahrenheit},
+			want:  25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if got := tt.ctx.TemperatureCelsius(); got != tt.want {
+				t.Errorf("unexpected temperature: %f != %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookTemperatureCelsius(t *testing.T) {
+	tests := []struct {
+		label string
+		body  string
+	}{
+		{
+			label: "meter",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeter","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"timeOfSample":123456789}}`,
+		},
+		{
+			label: "meter plus",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterPlus","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"timeOfSample":123456789}}`,
+		},
+		{
+			label: "outdoor meter",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoIOSensor","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"timeOfSample":123456789}}`,
+		},
+		{
+			label: "meter pro",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterPro","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"timeOfSample":123456789}}`,
+		},
+		{
+			label: "meter pro co2",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoMeterProCO2","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"CO2":812,"timeOfSample":123456789}}`,
+		},
+		{
+			label: "hub 3",
+			body:  `{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoHub3","deviceMac":"01:00:5e:90:10:00","temperature":77,"scale":"FAHRENHEIT","humidity":31,"lightLevel":10,"detectionState":"NOT_DETECTED","timeOfSample":123456789}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+
+			event, err := switchbot.ParseWebhookRequest(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got float64
+			switch event := event.(type) {
+			case *switchbot.MeterEvent:
+				got = event.Context.TemperatureCelsius()
+			case *switchbot.MeterPlusEvent:
+				got = event.Context.TemperatureCelsius()
+			case *switchbot.OutdoorMeterEvent:
+				got = event.Context.TemperatureCelsius()
+			case *switchbot.MeterProEvent:
+				got = event.Context.TemperatureCelsius()
+			case *switchbot.MeterProCO2Event:
+				got = event.Context.TemperatureCelsius()
+			case *switchbot.Hub3Event:
+				got = event.Context.TemperatureCelsius()
+			default:
+				t.Fatalf("unexpected event type: %T", event)
+			}
+
+			if got != 25 {
+				t.Errorf("unexpected temperature: %f != 25", got)
+			}
+		})
+	}
+}
+
+func TestNewWebhookTestRequest(t *testing.T) {
+	want := &switchbot.MeterEvent{
+		EventType:    "changeReport",
+		EventVersion: "1",
+		Context: switchbot.MeterEventContext{
+			DeviceType:   "WoMeter",
+			DeviceMac:    "01:00:5e:90:10:00",
+			Temperature:  22.5,
+			Scale:        switchbot.Celsius,
+			Humidity:     31,
+			TimeOfSample: 123456789,
+		},
+	}
+
+	r, err := switchbot.NewWebhookTestRequest(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Method != http.MethodPost {
+		t.Errorf("unexpected method: %s != %s", r.Method, http.MethodPost)
+	}
+
+	got, err := switchbot.ParseWebhookRequestAs[switchbot.MeterEvent](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("event mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestContactSensorDoorMode(t *testing.T) {
+	tests := []struct {
+		label string
+		mode  string
+		want  switchbot.DoorMode
+	}{
+		{label: "in door", mode: "IN_DOOR", want: switchbot.InDoor},
+		{label: "out door", mode: "OUT_DOOR", want: switchbot.OutDoor},
+		{label: "unknown", mode: "SOMEWHERE", want: switchbot.DoorMode("SOMEWHERE")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			body := fmt.Sprintf(`{"eventType":"changeReport","eventVersion":"1","context":{"deviceType":"WoContact","deviceMac":"01:00:5e:90:10:00","detectionState":"DETECTED","doorMode":%q,"brightness":"bright","openState":"open","timeOfSample":123456789}}`, tt.mode)
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+			event, err := switchbot.ParseWebhookRequestAs[switchbot.ContactSensorEvent](r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := event.Context.DoorMode; got != tt.want {
+				t.Errorf("unexpected door mode: %s != %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int { return &i }